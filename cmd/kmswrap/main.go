@@ -0,0 +1,61 @@
+// Command kmswrap wraps a secret with the local KMS (KMS_PROVIDER=local)
+// for storage in the secret store as a "kms:..." value.
+//
+// Usage:
+//
+//	openssl rand -base64 32 | go run ./cmd/kmswrap -name SECRET_PASETO_SIGNING_KEY
+//
+// The wrapping key is created on first use.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kararnab/authdemo/pkg/kms"
+)
+
+func main() {
+	path := flag.String("kms-path", "kms-local.json", "local KMS file (KMS_LOCAL_PATH)")
+	keyID := flag.String("key-id", "authdemo", "wrapping key (KMS_KEY_ID)")
+	name := flag.String("name", "", "secret name, e.g. SECRET_PASETO_SIGNING_KEY")
+	flag.Parse()
+
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "kmswrap: -name is required")
+		os.Exit(2)
+	}
+
+	if err := run(context.Background(), *path, *keyID, *name); err != nil {
+		fmt.Fprintln(os.Stderr, "kmswrap:", err)
+		os.Exit(1)
+	}
+}
+
+// run reads the secret from stdin and prints its wrapped value.
+func run(ctx context.Context, path, keyID, name string) error {
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && value == "" {
+		return fmt.Errorf("read secret from stdin: %w", err)
+	}
+	value = strings.TrimSpace(value)
+
+	local, err := kms.NewLocal(path)
+	if err != nil {
+		return err
+	}
+	if err := local.CreateKey(ctx, keyID, kms.KeySpecAES256); err != nil {
+		return err
+	}
+
+	wrapped, err := kms.WrapSecret(ctx, local, keyID, name, value)
+	if err != nil {
+		return err
+	}
+	fmt.Println(wrapped)
+	return nil
+}
@@ -2,11 +2,17 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 	//"log"
 
@@ -26,8 +32,13 @@ import (
 	"github.com/kararnab/authdemo/pkg/iam/token"
 	"github.com/kararnab/authdemo/pkg/iam/token/jwt"
 	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys/keyring"
+	"github.com/kararnab/authdemo/pkg/iam/token/opaque"
 	"github.com/kararnab/authdemo/pkg/iam/token/paseto"
+	"github.com/kararnab/authdemo/pkg/iam/token/pop"
+	"github.com/kararnab/authdemo/pkg/iam/token/revocation"
 
+	"github.com/kararnab/authdemo/pkg/kms"
 	"github.com/kararnab/authdemo/pkg/log"
 	zlog "github.com/kararnab/authdemo/pkg/log/zerolog"
 
@@ -36,6 +47,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/crypto/bcrypt"
+	_ "modernc.org/sqlite" // database/sql driver "sqlite" (pure Go, CGO_ENABLED=0 friendly)
 )
 
 //
@@ -51,8 +63,29 @@ import (
 const (
 	googleOAuthIssuerUrl = "https://accounts.google.com"
 	jwtIssuer            = "auth-monolith"
+	jwtAudience          = "authdemo-api"
 	jwtAccessTTL         = 15 * time.Minute
-	sessionTTL           = 24 * time.Hour
+	exchangeTTL          = 5 * time.Minute     // max lifetime of tokens obtained by token exchange
+	sessionTTL           = 24 * time.Hour      // sliding: extended on every refresh...
+	sessionMaxLifetime   = 30 * 24 * time.Hour // ...up to this long after login
+	sessionIdleTimeout   = 12 * time.Hour      // unused sessions die sooner
+	sessionMaxPerSubject = 10                  // concurrent logins, least recently used evicted
+	sessionMaxPerDevice  = 1                   // re-login on a device (X-Device-ID) replaces its session
+	sessionSweepInterval = time.Minute         // expired session cleanup (memory / sql stores)
+	keyPrePublish        = time.Hour           // next signing key is in the JWKS this long before it signs
+	keyRetireGrace       = 5 * time.Minute     // clock skew of verifiers: keys retire after the token TTL plus this
+	shutdownTimeout      = 10 * time.Second
+	defaultSQLitePath    = "sessions.db"
+	defaultKMSLocalPath  = "kms-local.json"
+	defaultKMSKeyID      = "authdemo"
+)
+
+// Access token formats (TOKEN_FORMAT).
+const (
+	tokenFormatJWT          = "jwt"           // JWT (HS256 / RS256 / ES256 / EdDSA)
+	tokenFormatPasetoLocal  = "paseto"        // PASETO v2.local (symmetric, encrypted)
+	tokenFormatPasetoPublic = "paseto-public" // PASETO v4.public (Ed25519, signed)
+	tokenFormatOpaque       = "opaque"        // random handle, claims kept server-side
 )
 
 func main() {
@@ -72,7 +105,11 @@ func main() {
 	// -------------------------------
 	// IAM + infra wiring
 	// -------------------------------
-	iamService, userStore, keyProvider, err := buildIAMService(iamMetrics)
+	// Cancelled on SIGINT / SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	iamService, userStore, keyProvider, janitor, keyScheduler, err := buildIAMService(iamMetrics)
 	if err != nil {
 		log.Error(
 			"failed to start IAM",
@@ -80,33 +117,109 @@ func main() {
 		)
 		os.Exit(1)
 	}
+	if janitor != nil {
+		janitor.Start(ctx)
+	}
+	if keyScheduler != nil {
+		keyScheduler.Start(ctx)
+	}
 
 	// -------------------------------
 	// HTTP API
 	// -------------------------------
-	authHandlers := api.NewHandlers(iamService, userStore)
+	clientResolver, err := buildClientInfoResolver()
+	if err != nil {
+		log.Error(
+			"invalid TRUSTED_PROXIES",
+			log.F("error", err, log.RedactNone),
+		)
+		os.Exit(1)
+	}
+
+	dpop, err := buildDPoP()
+	if err != nil {
+		log.Error(
+			"invalid DPOP_MODE",
+			log.F("error", err, log.RedactNone),
+		)
+		os.Exit(1)
+	}
+
+	authHandlers := api.NewHandlers(iamService, userStore, clientResolver, dpop)
 	bookStore := books.NewMemoryStore()
 	bookHandlers := api.NewBookHandlers(bookStore)
-	keyRotationHandler := api.NewKeyRotationHandler(keyProvider)
+	keyRotationHandler := api.NewKeyRotationHandler(keyProvider, &stdout.AuditLogger{}, clientResolver)
+
+	// Opaque access tokens have no signing keys: publish an empty set.
+	var jwksKeys keys.Provider
+	if keyProvider != nil {
+		jwksKeys = keyProvider
+	}
+	jwksHandler := api.NewJWKSHandler(jwksKeys)
+
+	oauthClients, err := buildOAuthClients()
+	if err != nil {
+		log.Error(
+			"invalid SECRET_OAUTH_CLIENTS",
+			log.F("error", err, log.RedactNone),
+		)
+		os.Exit(1)
+	}
+	introspectionHandler := api.NewIntrospectionHandler(iamService, oauthClients, clientResolver)
+	tokenHandler := api.NewTokenHandler(iamService, oauthClients, clientResolver)
+
 	metricsHandler := promhttp.HandlerFor(
 		registry,
 		promhttp.HandlerOpts{},
 	)
 
-	router := api.NewRouter(authHandlers, bookHandlers, keyRotationHandler, metricsHandler)
+	router := api.NewRouter(authHandlers, bookHandlers, keyRotationHandler, jwksHandler, introspectionHandler, tokenHandler, metricsHandler)
 
 	portAddr := ":" + getPort()
 	log.Info(
 		"🚀 Server running",
 		log.F("addr", portAddr, log.RedactNone),
 	)
-	if err := http.ListenAndServe(portAddr, router); err != nil {
-		//log.Fatalf("server failed: %v", err)
+	server := &http.Server{Addr: portAddr, Handler: router}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			//log.Fatalf("server failed: %v", err)
+			log.Error(
+				"server failed",
+				log.F("error", err, log.RedactNone),
+			)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Error(
-			"server failed",
+			"server shutdown failed",
 			log.F("error", err, log.RedactNone),
 		)
-		os.Exit(1)
+	}
+	if janitor != nil {
+		if err := janitor.Close(shutdownCtx); err != nil {
+			log.Error(
+				"session janitor shutdown failed",
+				log.F("error", err, log.RedactNone),
+			)
+		}
+	}
+	if keyScheduler != nil {
+		if err := keyScheduler.Close(shutdownCtx); err != nil {
+			log.Error(
+				"key rotation scheduler shutdown failed",
+				log.F("error", err, log.RedactNone),
+			)
+		}
 	}
 }
 
@@ -121,7 +234,9 @@ func buildIAMService(
 ) (
 	iam.Service,
 	internalprov.UserStore,
-	*keys.MemoryProvider,
+	*keyring.Keyring, // nil for opaque tokens
+	*session.Janitor, // nil if the store expires sessions itself
+	*keyring.Scheduler, // nil unless KEY_ROTATION_INTERVAL is set
 	error,
 ) {
 
@@ -134,7 +249,22 @@ func buildIAMService(
 	secretUserName, _ := store.Get(ctx, "SECRET_USERNAME")
 	secretJWTSigningKey, _ := store.Get(ctx, "SECRET_JWT_SIGNING_KEY")
 	secretPasetoSigningKey, _ := store.Get(ctx, "SECRET_PASETO_SIGNING_KEY")
+	jwtSigningAlg, _ := store.Get(ctx, "JWT_SIGNING_ALG")
+	tokenFormat, _ := store.Get(ctx, "TOKEN_FORMAT")
+	sessionStoreKind, _ := store.Get(ctx, "SESSION_STORE")
+	sessionSQLitePath, _ := store.Get(ctx, "SESSION_SQLITE_PATH")
+	redisAddr, _ := store.Get(ctx, "REDIS_ADDR")
+	secretRedisPassword, _ := store.Get(ctx, "SECRET_REDIS_PASSWORD")
 	googleOAuthClientID, _ := store.Get(ctx, "GOOGLE_OAUTH_CLIENTID")
+	keyringKind, _ := store.Get(ctx, "KEYRING_STORE")
+	keyringPath, _ := store.Get(ctx, "KEYRING_PATH")
+	secretKeyringMasterKey, _ := store.Get(ctx, "SECRET_KEYRING_MASTER_KEY")
+	kmsProvider, _ := store.Get(ctx, "KMS_PROVIDER")
+	kmsLocalPath, _ := store.Get(ctx, "KMS_LOCAL_PATH")
+	kmsKeyID, _ := store.Get(ctx, "KMS_KEY_ID")
+	keyRotationInterval, _ := store.Get(ctx, "KEY_ROTATION_INTERVAL")
+	legacyVerifiers, _ := store.Get(ctx, "LEGACY_VERIFIERS")
+	legacyVerifiersUntil, _ := store.Get(ctx, "LEGACY_VERIFIERS_UNTIL")
 
 	// -------------------------------
 	// User store (application-owned)
@@ -146,7 +276,7 @@ func buildIAMService(
 		bcrypt.DefaultCost,
 	)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, nil, err
 	}
 
 	if err := userStore.Create(ctx, &internalprov.User{
@@ -155,7 +285,7 @@ func buildIAMService(
 		PasswordHash: string(hash),
 		Roles:        []string{policy.Admin},
 	}); err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, nil, err
 	}
 
 	// -------------------------------
@@ -173,78 +303,237 @@ func buildIAMService(
 	// -------------------------------
 	// Sessions
 	// -------------------------------
-	sessionStore := session.NewMemoryStore()
+	sessionStore, sessionDB, err := buildSessionStore(ctx, sessionStoreConfig{
+		kind:          sessionStoreKind,
+		sqlitePath:    sessionSQLitePath,
+		redisAddr:     redisAddr,
+		redisPassword: secretRedisPassword,
+	})
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	denylist, err := buildDenylist(ctx, sessionDB)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	var janitor *session.Janitor
+	if sweeper, ok := sessionStore.(session.Sweeper); ok {
+		janitor = session.NewJanitor(sweeper, session.JanitorOptions{
+			Interval: sessionSweepInterval,
+			Metrics:  iamMetrics,
+		})
+	}
+
 	sessionManager := session.NewManager(
 		sessionStore,
-		sessionTTL,
+		session.ManagerOptions{
+			TTL:         sessionTTL,
+			MaxLifetime: sessionMaxLifetime,
+			IdleTimeout: sessionIdleTimeout,
+			Limits: session.LimitPolicy{
+				MaxPerSubject: sessionMaxPerSubject,
+				MaxPerDevice:  sessionMaxPerDevice,
+				Eviction:      session.EvictLeastRecentlyUsed,
+			},
+		},
 	)
 
 	// -------------------------------
 	// Tokens + keys
 	// -------------------------------
+	// Verifiers do not check "aud": the IAM service does, for its own
+	// API only, so exchanged tokens for other services can still be
+	// introspected and exchanged further.
 	var (
 		issuer      token.Issuer
-		verifier    token.Verifier
-		keyProvider *keys.MemoryProvider
+		route       token.Route // verification of the issued format
+		keyProvider *keyring.Keyring
 	)
 
-	pasetoKeyB64 := secretPasetoSigningKey
+	// Wraps the keyring data keys and "kms:" secrets.
+	keyKMS, kmsKeyID, err := buildKMS(ctx, kmsConfig{
+		provider:  kmsProvider,
+		localPath: kmsLocalPath,
+		keyID:     kmsKeyID,
+		masterKey: secretKeyringMasterKey,
+	})
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	keyringCfg := keyringConfig{
+		kind:     keyringKind,
+		path:     keyringPath,
+		kms:      keyKMS,
+		kmsKeyID: kmsKeyID,
+		db:       sessionDB,
+	}
 
-	if pasetoKeyB64 != "" {
-		rawKey, err := base64.StdEncoding.DecodeString(pasetoKeyB64)
+	pasetoKeyB64, err := kms.UnwrapSecret(ctx, keyKMS, kmsKeyID, "SECRET_PASETO_SIGNING_KEY", secretPasetoSigningKey)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	format := tokenFormat
+	if format == "" {
+		// Legacy selection: a PASETO key implies v2.local.
+		format = tokenFormatJWT
+		if pasetoKeyB64 != "" {
+			format = tokenFormatPasetoLocal
+		}
+	}
+
+	switch format {
+	case tokenFormatPasetoPublic:
+		// ================================
+		// PASETO v4.public (Ed25519)
+		// ================================
+		// Partners verify with the public key only.
+		keyProvider, err = openKeyring(ctx, keyringCfg, format, keys.EdDSA, func() (keys.Key, error) {
+			return keys.Generate("paseto-public-1", keys.EdDSA)
+		})
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("invalid PASETO_KEY: %w", err)
+			return nil, nil, nil, nil, nil, err
 		}
-		if len(rawKey) != 32 {
-			return nil, nil, nil, fmt.Errorf("PASETO_KEY must be 32 bytes")
+
+		issuer, err = paseto.NewPublicIssuer(
+			keyProvider,
+			jwtIssuer,
+			jwtAccessTTL,
+		)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+
+		route = token.Route{
+			Format: token.FormatPasetoV4Public,
+			Issuer: jwtIssuer,
+			Verifier: &token.MultiVerifier{
+				Verifier:    paseto.NewPublicVerifier(jwtIssuer, ""),
+				KeyProvider: keyProvider,
+				Metrics:     iamMetrics,
+			},
 		}
 
-		keyProvider = keys.NewMemoryProvider(keys.Key{
-			ID:  "paseto-1",
-			Key: rawKey,
+	case tokenFormatPasetoLocal:
+		keyProvider, err = openKeyring(ctx, keyringCfg, format, keys.HS256, func() (keys.Key, error) {
+			legacy, err := pasetoLocalKeys(pasetoKeyB64)
+			if err != nil {
+				return keys.Key{}, err
+			}
+			return legacy.ActiveKey(), nil
 		})
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
 
 		issuer, err = paseto.NewIssuer(
-			keyProvider.ActiveKey().Key,
-			keyProvider.ActiveKey().ID,
+			keyProvider,
 			jwtIssuer,
 			jwtAccessTTL,
 		)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, nil, err
 		}
 
-		verifier = &token.MultiVerifier{
-			Verifier:    paseto.NewVerifier(jwtIssuer),
-			KeyProvider: keyProvider,
+		route = token.Route{
+			Format: token.FormatPasetoV2Local,
+			Verifier: &token.MultiVerifier{
+				Verifier:    paseto.NewVerifier(jwtIssuer, ""),
+				KeyProvider: keyProvider,
+				Metrics:     iamMetrics,
+			},
 		}
 
-	} else {
+	case tokenFormatJWT:
 		// ================================
-		// JWT (fallback)
+		// JWT (default)
 		// ================================
-		signingKey := []byte(secretJWTSigningKey)
+		alg := keys.Algorithm(jwtSigningAlg)
+		if alg == "" {
+			alg = keys.HS256
+		}
 
-		keyProvider = keys.NewMemoryProvider(keys.Key{
-			ID:  "jwt-1",
-			Key: signingKey,
+		keyProvider, err = openKeyring(ctx, keyringCfg, format, alg, func() (keys.Key, error) {
+			if alg == keys.HS256 {
+				return keys.Key{
+					ID:        "jwt-1",
+					Algorithm: keys.HS256,
+					Key:       []byte(secretJWTSigningKey),
+				}, nil
+			}
+			// Asymmetric: verifiers only need the public half (see /.well-known/jwks.json).
+			return keys.Generate("jwt-1", alg)
 		})
+		if err != nil {
+			return nil, nil, nil, nil, nil, fmt.Errorf("invalid JWT_SIGNING_ALG: %w", err)
+		}
 
-		issuer = jwt.NewIssuer(
-			keyProvider.ActiveKey().Key,
-			keyProvider.ActiveKey().ID,
+		issuer, err = jwt.NewIssuer(
+			keyProvider,
 			jwtIssuer,
 			jwtAccessTTL,
 		)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
 
-		verifier = &token.MultiVerifier{
-			Verifier:    jwt.NewVerifier(jwtIssuer),
-			KeyProvider: keyProvider,
+		route = token.Route{
+			Format: token.FormatJWT,
+			Issuer: jwtIssuer,
+			Verifier: &token.MultiVerifier{
+				Verifier:    jwt.NewVerifier(jwtIssuer, ""),
+				KeyProvider: keyProvider,
+				Metrics:     iamMetrics,
+			},
 		}
+
+	case tokenFormatOpaque:
+		// ================================
+		// Opaque (reference) tokens
+		// ================================
+		// No signing keys: tokens are looked up in the store;
+		// other services validate them via /oauth/introspect.
+		opaqueStore := opaque.NewMemoryStore()
+
+		issuer = opaque.NewIssuer(opaqueStore, jwtAccessTTL)
+		route = token.Route{
+			Format:   token.FormatOpaque,
+			Verifier: opaque.NewVerifier(opaqueStore, ""),
+		}
+
+	default:
+		return nil, nil, nil, nil, nil, fmt.Errorf("unknown TOKEN_FORMAT %q", format)
 	}
 
-	if keyProvider == nil {
-		return nil, nil, nil, fmt.Errorf("no signing key configured")
+	if keyProvider == nil && format != tokenFormatOpaque {
+		return nil, nil, nil, nil, nil, fmt.Errorf("no signing key configured")
+	}
+
+	keyScheduler, err := buildKeyScheduler(keyProvider, format, keyRotationInterval)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	// Tokens of formats no longer issued stay valid only if opted in
+	// (LEGACY_VERIFIERS, until a sunset), and tokens of trusted
+	// external issuers are accepted too.
+	legacyRoutes, err := legacyVerifierRoutes(legacyConfig{
+		formats:      legacyVerifiers,
+		until:        legacyVerifiersUntil,
+		jwtSecret:    secretJWTSigningKey,
+		pasetoKeyB64: pasetoKeyB64,
+	}, format, iamMetrics)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	issuerRoutes, err := buildTrustedIssuers(ctx, iamMetrics)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	verifier := &token.CompositeVerifier{
+		Routes: append(append([]token.Route{route}, legacyRoutes...), issuerRoutes...),
 	}
 
 	// -------------------------------
@@ -259,12 +548,525 @@ func buildIAMService(
 		PolicyEngine:   &policy.DefaultPolicy{},
 		AuditLogger:    &stdout.AuditLogger{},
 		Metrics:        iamMetrics,
+
+		// Logout / revocation also kills the session's access tokens.
+		Denylist:       denylist,
+		AccessTokenTTL: jwtAccessTTL,
+
+		// Tokens are only accepted by services expecting jwtAudience;
+		// key management additionally requires the admin:keys scope.
+		Audience:      []string{jwtAudience},
+		DefaultScopes: []string{api.ScopeBooksRead, api.ScopeBooksWrite},
+		RoleScopes: map[string][]string{
+			policy.Admin: {api.ScopeAdminKeys},
+		},
+
+		// Token exchange (POST /oauth/token): the books API may call the
+		// inventory service on the user's behalf, read-only.
+		ExchangeGrants: map[string]service.ExchangeGrant{
+			"books-api": {
+				Audiences: []string{"inventory-api"},
+				Scopes:    []string{api.ScopeBooksRead},
+				MaxTTL:    exchangeTTL,
+			},
+		},
+
+		// Re-read roles on refresh so role changes apply without re-login.
+		SubjectResolver: users.NewSubjectResolver(userStore, internalProvider.Name()),
 	})
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, nil, err
+	}
+
+	return iamService, userStore, keyProvider, janitor, keyScheduler, nil
+}
+
+// buildKeyScheduler rotates the signing keyring every interval
+// (a Go duration, e.g. "720h"); empty keeps rotation manual.
+//
+// Keys are retired once the longest-lived token they may have signed
+// (access or exchanged token) has expired.
+func buildKeyScheduler(
+	kr *keyring.Keyring,
+	format string,
+	interval string,
+) (*keyring.Scheduler, error) {
+
+	if interval == "" || kr == nil {
+		return nil, nil
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEY_ROTATION_INTERVAL: %w", err)
 	}
 
-	return iamService, userStore, keyProvider, nil
+	return keyring.NewScheduler(kr, keyring.SchedulerOptions{
+		RotationInterval: d,
+		PrePublish:       keyPrePublish,
+		MaxTokenTTL:      max(jwtAccessTTL, exchangeTTL) + keyRetireGrace,
+	})
+}
+
+// kmsConfig selects the KMS that wraps keyring data keys and "kms:"
+// secrets (KMS_PROVIDER).
+type kmsConfig struct {
+	provider  string // KMS_PROVIDER: "" (SECRET_KEYRING_MASTER_KEY, if set) | local
+	localPath string // KMS_LOCAL_PATH (local): default kms-local.json
+	keyID     string // KMS_KEY_ID: default "authdemo"
+	masterKey string // SECRET_KEYRING_MASTER_KEY: base64, 32 bytes
+}
+
+// buildKMS returns the configured KMS and wrapping key ID
+// (nil KMS when none is configured).
+//
+// Supported:
+//   - "": the master key from the secret store, if any
+//   - local: file-backed KMS for dev / CI (key created on first start)
+func buildKMS(
+	ctx context.Context,
+	cfg kmsConfig,
+) (kms.KMS, string, error) {
+
+	keyID := cfg.keyID
+	if keyID == "" {
+		keyID = defaultKMSKeyID
+	}
+
+	switch cfg.provider {
+	case "":
+		if cfg.masterKey == "" {
+			return nil, keyID, nil
+		}
+		raw, err := base64.StdEncoding.DecodeString(cfg.masterKey)
+		if err != nil || len(raw) != 32 {
+			return nil, "", fmt.Errorf("SECRET_KEYRING_MASTER_KEY must be 32 bytes, base64")
+		}
+		k, err := kms.NewStatic(keyID, raw)
+		return k, keyID, err
+
+	case "local":
+		path := cfg.localPath
+		if path == "" {
+			path = defaultKMSLocalPath
+		}
+		local, err := kms.NewLocal(path)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := local.CreateKey(ctx, keyID, kms.KeySpecAES256); err != nil {
+			return nil, "", err
+		}
+		return local, keyID, nil
+
+	default:
+		return nil, "", fmt.Errorf("unknown KMS_PROVIDER %q", cfg.provider)
+	}
+}
+
+// keyringConfig selects where signing keys are kept (KEYRING_STORE).
+type keyringConfig struct {
+	kind     string  // KEYRING_STORE: memory (default) | file | sql
+	path     string  // KEYRING_PATH (file): default keyring-<format>.json
+	kms      kms.KMS // wraps the data keys (nil: none configured)
+	kmsKeyID string
+	db       *sql.DB // sql: the session database (SESSION_STORE=sqlite)
+}
+
+// openKeyring loads the signing keyring of a token format, creating it
+// with initial when empty. Once created, the keyring (not the
+// SECRET_* bootstrap keys) is the source of truth.
+//
+// If the active key no longer uses alg (e.g. JWT_SIGNING_ALG changed),
+// a new key is rotated in; the old one still verifies live tokens.
+func openKeyring(
+	ctx context.Context,
+	cfg keyringConfig,
+	format string,
+	alg keys.Algorithm,
+	initial func() (keys.Key, error),
+) (*keyring.Keyring, error) {
+
+	wrapper := cfg.kms
+
+	var store keyring.Store
+	switch cfg.kind {
+	case "", "memory":
+		// Keys die with the process anyway: an ephemeral wrapping key will do.
+		store = keyring.NewMemoryStore()
+		if wrapper == nil {
+			master := make([]byte, 32)
+			if _, err := rand.Read(master); err != nil {
+				return nil, err
+			}
+			k, err := kms.NewStatic(cfg.kmsKeyID, master)
+			if err != nil {
+				return nil, err
+			}
+			wrapper = k
+		}
+
+	case "file":
+		path := cfg.path
+		if path == "" {
+			path = "keyring-" + format + ".json"
+		}
+		store = keyring.NewFileStore(path)
+
+	case "sql":
+		if cfg.db == nil {
+			return nil, fmt.Errorf("KEYRING_STORE=sql requires SESSION_STORE=sqlite")
+		}
+		if err := keyring.MigrateSQL(ctx, cfg.db); err != nil {
+			return nil, err
+		}
+		store = keyring.NewSQLStore(cfg.db, keyring.SQLite, format)
+
+	default:
+		return nil, fmt.Errorf("unknown KEYRING_STORE %q", cfg.kind)
+	}
+
+	if wrapper == nil {
+		return nil, fmt.Errorf("KEYRING_STORE=%s requires SECRET_KEYRING_MASTER_KEY or KMS_PROVIDER", cfg.kind)
+	}
+
+	kr, err := keyring.Open(ctx, keyring.Options{
+		Store:       store,
+		KMS:         wrapper,
+		KMSKeyID:    cfg.kmsKeyID,
+		Initial:     initial,
+		KeyIDPrefix: format,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if active := kr.ActiveKey(); active.Alg() != alg {
+		next, err := keys.Generate(keys.NewID(format), alg)
+		if err != nil {
+			return nil, err
+		}
+		if err := kr.Rotate(ctx, next); err != nil {
+			return nil, err
+		}
+		log.Info(
+			"signing algorithm changed: rotated in a new key",
+			log.F("previous_key_id", active.ID, log.RedactNone),
+			log.F("active_key_id", next.ID, log.RedactNone),
+		)
+	}
+
+	return kr, nil
+}
+
+// pasetoLocalKeys loads the PASETO v2.local key (base64, 32 bytes).
+func pasetoLocalKeys(keyB64 string) (*keys.MemoryProvider, error) {
+	rawKey, err := base64.StdEncoding.DecodeString(keyB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASETO_KEY: %w", err)
+	}
+	if len(rawKey) != 32 {
+		return nil, fmt.Errorf("PASETO_KEY must be 32 bytes")
+	}
+
+	return keys.NewMemoryProvider(keys.Key{
+		ID:  "paseto-1",
+		Key: rawKey,
+	}), nil
+}
+
+// legacyConfig opts in to verifying token formats that are no longer
+// issued, e.g. for a migration window after switching TOKEN_FORMAT.
+type legacyConfig struct {
+	formats      string // LEGACY_VERIFIERS: comma-separated jwt | paseto (empty: off)
+	until        string // LEGACY_VERIFIERS_UNTIL: RFC 3339 sunset, required with formats
+	jwtSecret    string // SECRET_JWT_SIGNING_KEY (HS256 JWTs)
+	pasetoKeyB64 string // SECRET_PASETO_SIGNING_KEY (PASETO v2.local)
+}
+
+// legacyVerifierRoutes keeps accepting the formats listed in
+// LEGACY_VERIFIERS, verified with their long-lived secrets, until
+// LEGACY_VERIFIERS_UNTIL:
+//   - jwt: HS256 JWTs (SECRET_JWT_SIGNING_KEY)
+//   - paseto: PASETO v2.local (SECRET_PASETO_SIGNING_KEY)
+//
+// Off by default: whoever holds one of these secrets can mint tokens,
+// so they must not outlive the migration away from them.
+func legacyVerifierRoutes(
+	cfg legacyConfig,
+	format string,
+	iamMetrics metrics.IAMMetrics,
+) ([]token.Route, error) {
+
+	if strings.TrimSpace(cfg.formats) == "" {
+		return nil, nil
+	}
+
+	if cfg.until == "" {
+		return nil, fmt.Errorf("LEGACY_VERIFIERS requires LEGACY_VERIFIERS_UNTIL (RFC 3339)")
+	}
+	until, err := time.Parse(time.RFC3339, cfg.until)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LEGACY_VERIFIERS_UNTIL: %w", err)
+	}
+	if !time.Now().Before(until) {
+		log.Warn(
+			"legacy token verifiers are past their sunset, ignoring LEGACY_VERIFIERS",
+			log.F("until", cfg.until, log.RedactNone),
+		)
+		return nil, nil
+	}
+
+	var routes []token.Route
+	for _, legacy := range strings.Split(cfg.formats, ",") {
+		legacy = strings.TrimSpace(legacy)
+		if legacy == "" || legacy == format {
+			continue // the current format has its own route
+		}
+
+		switch legacy {
+		case tokenFormatJWT:
+			if cfg.jwtSecret == "" {
+				return nil, fmt.Errorf("LEGACY_VERIFIERS=jwt requires SECRET_JWT_SIGNING_KEY")
+			}
+			routes = append(routes, token.Route{
+				Format: token.FormatJWT,
+				Issuer: jwtIssuer,
+				Verifier: &token.MultiVerifier{
+					Verifier: jwt.NewVerifier(jwtIssuer, ""),
+					KeyProvider: keys.NewMemoryProvider(keys.Key{
+						ID:        "jwt-1",
+						Algorithm: keys.HS256,
+						Key:       []byte(cfg.jwtSecret),
+					}),
+					Metrics: iamMetrics,
+				},
+				Until: until,
+			})
+
+		case tokenFormatPasetoLocal:
+			if cfg.pasetoKeyB64 == "" {
+				return nil, fmt.Errorf("LEGACY_VERIFIERS=paseto requires SECRET_PASETO_SIGNING_KEY")
+			}
+			keyProvider, err := pasetoLocalKeys(cfg.pasetoKeyB64)
+			if err != nil {
+				return nil, err
+			}
+			routes = append(routes, token.Route{
+				Format: token.FormatPasetoV2Local,
+				Verifier: &token.MultiVerifier{
+					Verifier:    paseto.NewVerifier(jwtIssuer, ""),
+					KeyProvider: keyProvider,
+					Metrics:     iamMetrics,
+				},
+				Until: until,
+			})
+
+		default:
+			return nil, fmt.Errorf("unknown LEGACY_VERIFIERS format %q", legacy)
+		}
+
+		log.Info(
+			"accepting legacy access tokens",
+			log.F("format", legacy, log.RedactNone),
+			log.F("until", cfg.until, log.RedactNone),
+		)
+	}
+
+	return routes, nil
+}
+
+// buildTrustedIssuers accepts JWT access tokens of external OIDC
+// identity providers (TRUSTED_ISSUERS, "issuer|jwks_url,...").
+//
+// Their keys are fetched from the JWKS URL; subjects are namespaced
+// by issuer and get no roles (grant them via the issuer's mapping).
+// Tokens must still be issued for this API's audience.
+func buildTrustedIssuers(
+	ctx context.Context,
+	iamMetrics metrics.IAMMetrics,
+) ([]token.Route, error) {
+
+	store := secret_store.BuildSecretStore()
+	raw, _ := store.Get(ctx, "TRUSTED_ISSUERS")
+
+	var routes []token.Route
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		iss, jwksURL, ok := strings.Cut(entry, "|")
+		if !ok || iss == "" || iss == jwtIssuer {
+			return nil, fmt.Errorf("invalid TRUSTED_ISSUERS entry %q", entry)
+		}
+
+		provider, err := keys.NewRemoteProvider(keys.RemoteOptions{
+			URL:     jwksURL,
+			Metrics: iamMetrics,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Not fatal: keys are fetched again when tokens arrive.
+		if err := provider.Refresh(ctx); err != nil {
+			log.Warn(
+				"trusted issuer JWKS fetch failed",
+				log.F("issuer", iss, log.RedactNone),
+				log.F("error", err, log.RedactNone),
+			)
+		}
+
+		routes = append(routes, token.Route{
+			Format: token.FormatJWT,
+			Issuer: iss,
+			Verifier: &token.MultiVerifier{
+				Verifier:    jwt.NewVerifier(iss, ""),
+				KeyProvider: provider,
+				Metrics:     iamMetrics,
+			},
+			Mapper: token.ClaimMapping{
+				SubjectPrefix: iss + "|",
+				AttrClaims: map[string]string{
+					"email": "email",
+				},
+			},
+		})
+	}
+
+	return routes, nil
+}
+
+// sessionStoreConfig selects and configures the session backend.
+type sessionStoreConfig struct {
+	kind          string // SESSION_STORE
+	sqlitePath    string // SESSION_SQLITE_PATH
+	redisAddr     string // REDIS_ADDR
+	redisPassword string // SECRET_REDIS_PASSWORD
+}
+
+// buildSessionStore selects the session backend (SESSION_STORE).
+//
+//   - memory (default): single instance, sessions lost on restart
+//   - sqlite: persistent, schema migrated on start
+//   - redis: shared between replicas, native TTLs
+//
+// The SQL database (nil for other stores) is returned so related
+// state (the access token denylist) can live next to the sessions.
+func buildSessionStore(
+	ctx context.Context,
+	cfg sessionStoreConfig,
+) (session.Store, *sql.DB, error) {
+
+	sqlitePath := cfg.sqlitePath
+
+	switch cfg.kind {
+	case "", "memory":
+		return session.NewMemoryStore(), nil, nil
+
+	case "sqlite":
+		if sqlitePath == "" {
+			sqlitePath = defaultSQLitePath
+		}
+
+		db, err := sql.Open("sqlite", sqlitePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open session db: %w", err)
+		}
+
+		// SQLite allows a single writer; avoid SQLITE_BUSY under load.
+		db.SetMaxOpenConns(1)
+
+		if err := session.MigrateSQL(ctx, db, session.SQLite); err != nil {
+			return nil, nil, err
+		}
+		return session.NewSQLStore(db, session.SQLite), db, nil
+
+	case "redis":
+		if cfg.redisAddr == "" {
+			return nil, nil, fmt.Errorf("REDIS_ADDR is required for SESSION_STORE=redis")
+		}
+		return session.NewRedisStore(session.RedisOptions{
+			Addr:     cfg.redisAddr,
+			Password: cfg.redisPassword,
+		}), nil, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown SESSION_STORE %q", cfg.kind)
+	}
+}
+
+// buildDenylist creates the access token denylist: persistent when
+// sessions are stored in SQL, in-memory otherwise.
+//
+// TODO (prod):
+//   - Redis store for SESSION_STORE=redis (shared across replicas)
+func buildDenylist(
+	ctx context.Context,
+	db *sql.DB,
+) (*revocation.Denylist, error) {
+
+	store := revocation.NewMemoryStore()
+	if db != nil {
+		if err := revocation.MigrateSQL(ctx, db); err != nil {
+			return nil, err
+		}
+		store = revocation.NewSQLStore(db, revocation.SQLite)
+	}
+
+	return revocation.New(ctx, store, revocation.Options{})
+}
+
+// buildClientInfoResolver configures client IP resolution (TRUSTED_PROXIES).
+//
+// Only set this when running behind a reverse proxy / load balancer;
+// otherwise X-Forwarded-For is client-controlled and ignored.
+func buildClientInfoResolver() (*api.ClientInfoResolver, error) {
+	store := secret_store.BuildSecretStore()
+	raw, _ := store.Get(context.Background(), "TRUSTED_PROXIES")
+
+	trusted, err := api.ParseTrustedProxies(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.NewClientInfoResolver(trusted), nil
+}
+
+// buildDPoP configures DPoP sender-constrained access tokens
+// (DPOP_MODE: off | optional | required).
+//
+// PUBLIC_BASE_URL must be set behind a proxy so the proof's htu
+// matches the URL the client actually called.
+func buildDPoP() (*api.DPoP, error) {
+	store := secret_store.BuildSecretStore()
+	rawMode, _ := store.Get(context.Background(), "DPOP_MODE")
+	baseURL, _ := store.Get(context.Background(), "PUBLIC_BASE_URL")
+
+	mode, err := api.ParseDPoPMode(rawMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.DPoP{
+		Mode: mode,
+		Verifier: &pop.DPoPVerifier{
+			Replay: pop.NewMemoryReplayCache(),
+		},
+		BaseURL: baseURL,
+	}, nil
+}
+
+// buildOAuthClients loads the services allowed to call /oauth/introspect
+// and /oauth/token (SECRET_OAUTH_CLIENTS, "id:secret,...").
+//
+// Empty: nobody can introspect or exchange tokens.
+func buildOAuthClients() (api.ClientCredentials, error) {
+	store := secret_store.BuildSecretStore()
+	raw, _ := store.Get(context.Background(), "SECRET_OAUTH_CLIENTS")
+
+	return api.ParseClientCredentials(raw)
 }
 
 func getPort() string {
@@ -2,11 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/kararnab/authdemo/pkg/iam"
 	internalprov "github.com/kararnab/authdemo/pkg/iam/provider/inhouse"
+	"github.com/kararnab/authdemo/pkg/iam/session"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -56,8 +59,14 @@ func (h *Handlers) Register(w http.ResponseWriter, r *http.Request) {
 type loginReq struct {
 	Provider string            `json:"provider"`
 	Params   map[string]string `json:"params"`
+
+	// DeviceKey is an optional public JWK binding the session to the device.
+	DeviceKey json.RawMessage `json:"device_key,omitempty"`
 }
 
+// headerDeviceProof carries the device proof JWS for device-bound sessions.
+const headerDeviceProof = "X-Device-Proof"
+
 func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
 	var req loginReq
 
@@ -71,10 +80,27 @@ func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dpopJKT, err := h.DPoP.tokenBinding(r)
+	if err != nil {
+		http.Error(w, "invalid DPoP proof", http.StatusBadRequest)
+		return
+	}
+
 	res, err := h.IAM.Authenticate(r.Context(), iam.AuthRequest{
-		Provider: req.Provider,
-		Params:   req.Params,
+		Provider:       req.Provider,
+		Params:         req.Params,
+		Client:         h.Clients.Resolve(r),
+		DeviceKey:      string(req.DeviceKey),
+		DPoPThumbprint: dpopJKT,
 	})
+	if errors.Is(err, session.ErrInvalidDeviceKey) {
+		http.Error(w, "invalid device_key", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, session.ErrSessionLimitReached) {
+		http.Error(w, "too many active sessions", http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
@@ -100,11 +126,19 @@ func (h *Handlers) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.IAM.Revoke(r.Context(), req.RefreshToken); err != nil {
+	ctx := session.WithDeviceProof(r.Context(), r.Header.Get(headerDeviceProof))
+
+	if err := h.IAM.Revoke(ctx, req.RefreshToken); err != nil {
 		http.Error(w, "invalid refresh token", http.StatusUnauthorized)
 		return
 	}
 
+	// The session's access tokens are revoked with it (sid); also drop
+	// the presented one in case it belongs to no session. Best effort.
+	if _, accessToken, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && accessToken != "" {
+		_ = h.IAM.RevokeAccessToken(ctx, accessToken)
+	}
+
 	writeJSON(w, http.StatusOK, map[string]string{
 		"status": "logged_out",
 	})
@@ -122,13 +156,25 @@ func (h *Handlers) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.IAM.Refresh(r.Context(), req.RefreshToken)
+	dpopJKT, err := h.DPoP.tokenBinding(r)
+	if err != nil {
+		http.Error(w, "invalid DPoP proof", http.StatusBadRequest)
+		return
+	}
+
+	ctx := iam.WithClient(r.Context(), h.Clients.Resolve(r))
+	ctx = session.WithDeviceProof(ctx, r.Header.Get(headerDeviceProof))
+	ctx = iam.WithDPoPThumbprint(ctx, dpopJKT)
+
+	res, err := h.IAM.Refresh(ctx, req.RefreshToken)
 	if err != nil {
 		http.Error(w, "invalid refresh token", http.StatusUnauthorized)
 		return
 	}
 
+	// The presented refresh token is now spent; the client must keep the new one.
 	writeJSON(w, http.StatusOK, map[string]string{
-		"access_token": token,
+		"access_token":  res.AccessToken,
+		"refresh_token": res.RefreshToken,
 	})
 }
@@ -10,6 +10,13 @@ import (
 	"github.com/kararnab/authdemo/internal/books"
 )
 
+// Book scopes, granted to every user token. Services acting for the
+// user (token exchange) are limited to the ones they were granted.
+const (
+	ScopeBooksRead  = "books:read"
+	ScopeBooksWrite = "books:write"
+)
+
 // BookHandlers exposes CRUD APIs for books.
 //
 // Responsibilities:
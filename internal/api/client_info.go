@@ -0,0 +1,120 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/kararnab/authdemo/pkg/iam"
+)
+
+// Client metadata headers (optional, set by first-party clients).
+const (
+	headerClientID = "X-Client-ID"
+	headerDeviceID = "X-Device-ID"
+)
+
+// ClientInfoResolver extracts iam.ClientInfo from HTTP requests.
+//
+// The client IP is the direct peer unless that peer is a trusted
+// proxy, in which case X-Forwarded-For is walked right to left and
+// the first untrusted hop wins. Headers from untrusted peers are
+// ignored, so clients cannot spoof their IP.
+type ClientInfoResolver struct {
+	trusted []netip.Prefix
+}
+
+// NewClientInfoResolver creates a resolver trusting the given proxy ranges.
+//
+// With no trusted proxies, the TCP peer address is always used.
+func NewClientInfoResolver(trustedProxies []netip.Prefix) *ClientInfoResolver {
+	return &ClientInfoResolver{trusted: trustedProxies}
+}
+
+// ParseTrustedProxies parses a comma-separated list of IPs / CIDRs
+// (e.g. "10.0.0.0/8, 127.0.0.1").
+func ParseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if strings.Contains(item, "/") {
+			p, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", item, err)
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", item, err)
+		}
+		out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return out, nil
+}
+
+// Resolve returns the client metadata for r.
+func (c *ClientInfoResolver) Resolve(r *http.Request) iam.ClientInfo {
+	return iam.ClientInfo{
+		IP:        c.clientIP(r),
+		UserAgent: r.UserAgent(),
+		ClientID:  r.Header.Get(headerClientID),
+		DeviceID:  r.Header.Get(headerDeviceID),
+	}
+}
+
+func (c *ClientInfoResolver) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !c.isTrusted(peer) {
+		return host
+	}
+
+	// Peer is our proxy: walk the chain it (and its peers) appended.
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+
+	ip := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // garbage: don't trust anything further left
+		}
+		ip = hop
+		if !c.isTrusted(hop) {
+			break
+		}
+	}
+
+	return ip.Unmap().String()
+}
+
+func (c *ClientInfoResolver) isTrusted(addr netip.Addr) bool {
+	if c == nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, p := range c.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kararnab/authdemo/pkg/iam"
+	"github.com/kararnab/authdemo/pkg/iam/token/pop"
+)
+
+// DPoPMode selects DPoP (RFC 9449) support.
+type DPoPMode int
+
+const (
+	// DPoPOff issues bearer tokens only; DPoP headers are ignored
+	// (sessions bound while DPoP was enabled can no longer refresh).
+	DPoPOff DPoPMode = iota
+
+	// DPoPOptional binds tokens for clients that send a DPoP proof
+	// at login / refresh; other clients get bearer tokens. Sessions
+	// of a DPoP login stay bound: their refreshes always need a
+	// proof by the same key.
+	DPoPOptional
+
+	// DPoPRequired rejects logins, refreshes and resource requests
+	// without DPoP.
+	DPoPRequired
+)
+
+// ParseDPoPMode parses DPOP_MODE ("", "off", "optional", "required").
+func ParseDPoPMode(raw string) (DPoPMode, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "off":
+		return DPoPOff, nil
+	case "optional":
+		return DPoPOptional, nil
+	case "required":
+		return DPoPRequired, nil
+	default:
+		return DPoPOff, fmt.Errorf("unknown DPoP mode %q", raw)
+	}
+}
+
+// headerDPoP carries the DPoP proof JWS.
+const headerDPoP = "DPoP"
+
+var errDPoP = errors.New("invalid or missing DPoP proof")
+
+// DPoP checks DPoP proofs at the token endpoints and on protected
+// resources.
+type DPoP struct {
+	Mode     DPoPMode
+	Verifier *pop.DPoPVerifier
+
+	// BaseURL is the public origin used to rebuild htu
+	// (e.g. "https://auth.example.com" behind a proxy).
+	// Default: derived from the request.
+	BaseURL string
+}
+
+func (d *DPoP) enabled() bool {
+	return d != nil && d.Mode != DPoPOff
+}
+
+// tokenBinding verifies the DPoP proof sent to login / refresh and
+// returns the key thumbprint to bind the new access token to
+// ("" for a bearer token).
+func (d *DPoP) tokenBinding(r *http.Request) (string, error) {
+	if !d.enabled() {
+		return "", nil
+	}
+
+	proof := r.Header.Get(headerDPoP)
+	if proof == "" {
+		if d.Mode == DPoPRequired {
+			return "", errDPoP
+		}
+		return "", nil
+	}
+
+	p, err := d.Verifier.Verify(r.Context(), proof, pop.DPoPRequest{
+		Method: r.Method,
+		URL:    d.htu(r),
+	})
+	if err != nil {
+		return "", errDPoP
+	}
+
+	return p.Thumbprint, nil
+}
+
+// checkResource enforces the binding of an access token presented
+// with the given Authorization scheme.
+//
+// DPoP-bound tokens are only accepted with the DPoP scheme and a
+// matching proof; bearer use of them is rejected.
+func (d *DPoP) checkResource(
+	r *http.Request,
+	scheme string,
+	accessToken string,
+	subject *iam.Subject,
+) error {
+
+	if subject.Confirmation == "" {
+		if scheme != "bearer" || (d.enabled() && d.Mode == DPoPRequired) {
+			return errDPoP
+		}
+		return nil
+	}
+
+	if scheme != "dpop" || !d.enabled() {
+		return errDPoP
+	}
+
+	_, err := d.Verifier.Verify(r.Context(), r.Header.Get(headerDPoP), pop.DPoPRequest{
+		Method:      r.Method,
+		URL:         d.htu(r),
+		AccessToken: accessToken,
+		Thumbprint:  subject.Confirmation,
+	})
+	if err != nil {
+		return errDPoP
+	}
+	return nil
+}
+
+// htu rebuilds the request URI as the client addressed it.
+func (d *DPoP) htu(r *http.Request) string {
+	if d.BaseURL != "" {
+		return strings.TrimRight(d.BaseURL, "/") + r.URL.Path
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
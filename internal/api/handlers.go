@@ -8,14 +8,20 @@ import (
 type Handlers struct {
 	IAM       iam.Service
 	UserStore internalprov.UserStore
+	Clients   *ClientInfoResolver
+	DPoP      *DPoP // optional
 }
 
 func NewHandlers(
 	iamSvc iam.Service,
 	userStore internalprov.UserStore,
+	clients *ClientInfoResolver,
+	dpop *DPoP,
 ) *Handlers {
 	return &Handlers{
 		IAM:       iamSvc,
 		UserStore: userStore,
+		Clients:   clients,
+		DPoP:      dpop,
 	}
 }
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// headerIdempotencyKey lets clients retry a non-idempotent request
+	// (e.g. after a timeout) without repeating its effect.
+	headerIdempotencyKey = "Idempotency-Key"
+
+	// headerIdempotentReplayed marks a replayed response.
+	headerIdempotentReplayed = "Idempotent-Replayed"
+
+	idempotencyTTL       = 24 * time.Hour
+	maxIdempotencyKeyLen = 255
+)
+
+// idempotentResponse is a stored successful response.
+type idempotentResponse struct {
+	status    int
+	body      any
+	expiresAt time.Time
+}
+
+// idempotencyCache replays responses of requests retried with the
+// same Idempotency-Key (in memory: single instance, lost on restart).
+//
+// Behavior:
+//   - requests are serialized: a retry arriving while the first
+//     request runs waits for it, then gets its response
+//   - only successful (2xx) responses are stored; failures may be
+//     retried with the same key
+//   - keys are scoped to the caller and the endpoint
+type idempotencyCache struct {
+	mu      sync.Mutex // held for the whole request
+	entries map[string]idempotentResponse
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{entries: make(map[string]idempotentResponse)}
+}
+
+// do runs op once per (scope, Idempotency-Key) and writes its
+// response: body as JSON, or err as plain text with the returned
+// status. Without the header, op always runs.
+func (c *idempotencyCache) do(
+	w http.ResponseWriter,
+	r *http.Request,
+	scope string,
+	op func() (int, any, error),
+) {
+
+	key := r.Header.Get(headerIdempotencyKey)
+	if len(key) > maxIdempotencyKeyLen {
+		http.Error(w, "Idempotency-Key too long", http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if key != "" {
+		key = scope + " " + r.Method + " " + r.URL.Path + " " + key
+		if resp, ok := c.entries[key]; ok && now.Before(resp.expiresAt) {
+			w.Header().Set(headerIdempotentReplayed, "true")
+			writeJSON(w, resp.status, resp.body)
+			return
+		}
+	}
+
+	status, body, err := op()
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if key != "" && status >= 200 && status < 300 {
+		for k, resp := range c.entries {
+			if !now.Before(resp.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.entries[key] = idempotentResponse{
+			status:    status,
+			body:      body,
+			expiresAt: now.Add(idempotencyTTL),
+		}
+	}
+
+	writeJSON(w, status, body)
+}
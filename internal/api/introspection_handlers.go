@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kararnab/authdemo/pkg/iam"
+	"github.com/kararnab/authdemo/pkg/iam/token/introspection"
+)
+
+// IntrospectionHandler serves OAuth 2.0 Token Introspection (RFC 7662).
+//
+// Only registered resource servers may call it (HTTP Basic with
+// client_id / client_secret); otherwise it would be a token oracle.
+type IntrospectionHandler struct {
+	IAM      iam.Service
+	Clients  ClientCredentials
+	Resolver *ClientInfoResolver // caller IP for audit (optional)
+}
+
+// NewIntrospectionHandler creates a new IntrospectionHandler instance.
+func NewIntrospectionHandler(
+	iamSvc iam.Service,
+	clients ClientCredentials,
+	resolver *ClientInfoResolver,
+) *IntrospectionHandler {
+	return &IntrospectionHandler{
+		IAM:      iamSvc,
+		Clients:  clients,
+		Resolver: resolver,
+	}
+}
+
+// Introspect ================================
+// POST /oauth/introspect
+// ================================
+func (h *IntrospectionHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.Clients.Authenticate(r)
+	if !ok {
+		writeInvalidClient(w, "introspection")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("token") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "invalid_request",
+		})
+		return
+	}
+
+	caller := h.Resolver.Resolve(r)
+	caller.ClientID = clientID
+	ctx := iam.WithClient(r.Context(), caller)
+
+	info, err := h.IAM.Introspect(ctx, r.PostForm.Get("token"))
+	if err != nil {
+		http.Error(w, "introspection failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, introspectionResponse(info))
+}
+
+// introspectionResponse maps token info to the RFC 7662 wire format.
+func introspectionResponse(info *iam.TokenInfo) introspection.Response {
+	if !info.Active {
+		return introspection.Response{Active: false}
+	}
+
+	resp := introspection.Response{
+		Active:    true,
+		Scope:     strings.Join(info.Subject.Scopes, " "),
+		TokenType: "Bearer",
+		Exp:       info.ExpiresAt.Unix(),
+		Iat:       info.IssuedAt.Unix(),
+		Sub:       info.Subject.ID,
+		Aud:       info.Audience,
+		Jti:       info.TokenID,
+		Sid:       info.SessionID,
+		Act:       introspection.NewActor(info.Subject.Actors),
+		Roles:     info.Subject.Roles,
+		Attrs:     info.Subject.Attrs,
+	}
+
+	if info.Subject.Confirmation != "" {
+		resp.TokenType = "DPoP"
+		resp.Cnf = &introspection.Confirmation{JKT: info.Subject.Confirmation}
+	}
+
+	return resp
+}
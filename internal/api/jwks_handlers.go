@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+)
+
+// JWKSHandler publishes the public halves of the signing keys.
+//
+// Downstream services fetch this document to verify access tokens
+// without ever holding signing material.
+// Symmetric keys are never published.
+type JWKSHandler struct {
+	Keys keys.Provider
+}
+
+// NewJWKSHandler creates a new JWKSHandler instance.
+func NewJWKSHandler(kp keys.Provider) *JWKSHandler {
+	return &JWKSHandler{Keys: kp}
+}
+
+// JWKS ================================
+// GET /.well-known/jwks.json
+// ================================
+func (h *JWKSHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	// Short cache: rotated keys must show up quickly,
+	// retained keys keep old tokens verifiable.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	writeJSON(w, http.StatusOK, keys.PublicJWKS(h.Keys))
+}
@@ -1,50 +1,205 @@
 package api
 
 import (
-	"crypto/rand"
-	"encoding/base64"
+	"errors"
+	"maps"
 	"net/http"
+	"time"
 
-	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/kararnab/authdemo/pkg/iam/audit"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys/keyring"
 )
 
+// ScopeAdminKeys is the access token scope required for key management.
+const ScopeAdminKeys = "admin:keys"
+
+// KeyRotationHandler manages the signing keyring (admin only, see
+// router).
+//
+// Responses carry key metadata only: key material never leaves the
+// keyring over HTTP. Every change is audited with the acting admin;
+// rotate and retire accept an Idempotency-Key. Stored keys are wrapped
+// by the keyring's KMS (see pkg/kms).
 type KeyRotationHandler struct {
-	Keys *keys.MemoryProvider
+	Keys     *keyring.Keyring    // nil for opaque access tokens
+	Audit    audit.Logger        // key changes
+	Resolver *ClientInfoResolver // caller IP for audit
+
+	idempotency *idempotencyCache
 }
 
-func NewKeyRotationHandler(kp *keys.MemoryProvider) *KeyRotationHandler {
-	return &KeyRotationHandler{Keys: kp}
+// NewKeyRotationHandler creates a new KeyRotationHandler instance.
+func NewKeyRotationHandler(
+	kp *keyring.Keyring,
+	auditLogger audit.Logger,
+	resolver *ClientInfoResolver,
+) *KeyRotationHandler {
+	return &KeyRotationHandler{
+		Keys:        kp,
+		Audit:       auditLogger,
+		Resolver:    resolver,
+		idempotency: newIdempotencyCache(),
+	}
+}
+
+// keyResponse is the metadata of a signing key.
+type keyResponse struct {
+	ID            string    `json:"id"`
+	Algorithm     string    `json:"algorithm"`
+	State         string    `json:"state"`
+	CreatedAt     time.Time `json:"created_at"`
+	ActivatedAt   time.Time `json:"activated_at,omitzero"`
+	DeactivatedAt time.Time `json:"deactivated_at,omitzero"`
+	RetiredAt     time.Time `json:"retired_at,omitzero"`
+}
+
+func newKeyResponse(k keyring.KeyInfo) keyResponse {
+	return keyResponse{
+		ID:            k.ID,
+		Algorithm:     string(k.Algorithm),
+		State:         string(k.State),
+		CreatedAt:     k.CreatedAt,
+		ActivatedAt:   k.ActivatedAt,
+		DeactivatedAt: k.DeactivatedAt,
+		RetiredAt:     k.RetiredAt,
+	}
 }
 
+// List ================================
+// GET /admin/keys
+// ================================
+func (h *KeyRotationHandler) List(w http.ResponseWriter, r *http.Request) {
+	if h.Keys == nil {
+		http.Error(w, "no signing keys (opaque access tokens)", http.StatusNotFound)
+		return
+	}
+
+	infos := h.Keys.Keys()
+	out := make([]keyResponse, 0, len(infos))
+	for _, k := range infos {
+		out = append(out, newKeyResponse(k))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"keys": out})
+}
+
+// Rotate ================================
+// POST /admin/keys/rotate
+// ================================
 func (h *KeyRotationHandler) Rotate(w http.ResponseWriter, r *http.Request) {
-	// TODO:
-	//   - Enforce admin-only policy
-	//   - Audit event
-	//   - External KMS integration
-
-	newKey := make([]byte, 32)
-	if _, err := rand.Read(newKey); err != nil {
-		http.Error(w, "failed to generate key", http.StatusInternalServerError)
+	if h.Keys == nil {
+		http.Error(w, "no signing keys (opaque access tokens)", http.StatusNotFound)
 		return
 	}
 
-	prev := h.Keys.ActiveKey()
+	adminID := h.adminID(r)
+
+	h.idempotency.do(w, r, adminID, func() (int, any, error) {
+		prev := h.Keys.ActiveKey()
 
-	newKeyID := prev.ID + "-rotated" // TODO: better ID scheme
+		// New key keeps the algorithm of the key it replaces.
+		newKey, err := h.Keys.NewKey()
+		if err != nil {
+			h.audit(r, audit.EventKeyRotated, adminID, "signing key rotation failed", map[string]string{
+				"previous_key_id": prev.ID,
+				"error":           err.Error(),
+			})
+			return http.StatusInternalServerError, nil, errors.New("failed to generate key")
+		}
 
-	h.Keys.Rotate(keys.Key{
-		ID:  newKeyID,
-		Key: newKey,
+		// Persisted before use: a restart must not lose the key of live tokens.
+		if err := h.Keys.Rotate(r.Context(), newKey); err != nil {
+			h.audit(r, audit.EventKeyRotated, adminID, "signing key rotation failed", map[string]string{
+				"previous_key_id": prev.ID,
+				"error":           err.Error(),
+			})
+			return http.StatusInternalServerError, nil, errors.New("failed to store key")
+		}
+
+		h.audit(r, audit.EventKeyRotated, adminID, "signing key rotated", map[string]string{
+			"key_id":          newKey.ID,
+			"previous_key_id": prev.ID,
+		})
+
+		return http.StatusOK, map[string]string{
+			"status":          "rotated",
+			"active_key_id":   newKey.ID,
+			"previous_key_id": prev.ID,
+		}, nil
 	})
+}
+
+// Retire ================================
+// POST /admin/keys/{id}/retire
+// ================================
+//
+// Tokens signed with the key are rejected from now on (e.g. after a
+// compromise). The active key must be rotated out first.
+func (h *KeyRotationHandler) Retire(w http.ResponseWriter, r *http.Request) {
+	if h.Keys == nil {
+		http.Error(w, "no signing keys (opaque access tokens)", http.StatusNotFound)
+		return
+	}
+
+	adminID := h.adminID(r)
+	id := chi.URLParam(r, "id")
+
+	h.idempotency.do(w, r, adminID, func() (int, any, error) {
+		err := h.Keys.Retire(r.Context(), id)
+		if err != nil {
+			h.audit(r, audit.EventKeyRetired, adminID, "signing key retirement rejected", map[string]string{
+				"key_id": id,
+				"error":  err.Error(),
+			})
+		}
+		switch {
+		case errors.Is(err, keyring.ErrKeyNotFound):
+			return http.StatusNotFound, nil, errors.New("key not found")
+		case errors.Is(err, keyring.ErrActiveKey):
+			return http.StatusConflict, nil, errors.New("the active key cannot be retired, rotate first")
+		case err != nil:
+			return http.StatusInternalServerError, nil, errors.New("failed to store key")
+		}
 
-	writeJSON(w, http.StatusOK, map[string]string{
-		"status":            "rotated",
-		"active_key_id":     newKeyID,
-		"previous_key_id":   prev.ID,
-		"active_key_base64": base64.StdEncoding.EncodeToString(newKey), // ⚠️ REMOVE IN PROD
+		h.audit(r, audit.EventKeyRetired, adminID, "signing key retired", map[string]string{
+			"key_id": id,
+		})
+
+		for _, k := range h.Keys.Keys() {
+			if k.ID == id {
+				return http.StatusOK, newKeyResponse(k), nil
+			}
+		}
+		return http.StatusNotFound, nil, errors.New("key not found")
 	})
 }
 
-// ⚠️ Important
-// The base64 key is returned only for demo/debug.
-// In real systems, keys go to Vault/KMS, never over HTTP.
+// adminID is the subject acting on the keys (set by AuthMiddleware).
+func (h *KeyRotationHandler) adminID(r *http.Request) string {
+	if s, ok := SubjectFromContext(r.Context()); ok {
+		return s.ID
+	}
+	return ""
+}
+
+// audit records a key management event with the caller's client info.
+func (h *KeyRotationHandler) audit(
+	r *http.Request,
+	event audit.EventType,
+	adminID string,
+	message string,
+	attrs map[string]string,
+) {
+
+	out := h.Resolver.Resolve(r).Attrs()
+	maps.Copy(out, attrs)
+
+	_ = h.Audit.Log(r.Context(), audit.Event{
+		Type:      event,
+		SubjectID: adminID,
+		Message:   message,
+		Attrs:     out,
+	})
+}
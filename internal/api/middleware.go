@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/kararnab/authdemo/pkg/iam"
@@ -13,34 +15,50 @@ type ctxKey string
 
 const subjectKey ctxKey = "subject"
 
-func AuthMiddleware(iamSvc iam.Service) func(http.Handler) http.Handler {
+// AuthMiddleware authenticates requests with an access token.
+//
+// Accepts "Authorization: Bearer <token>" and, for DPoP-bound
+// tokens, "Authorization: DPoP <token>" plus a DPoP proof header.
+// dpop may be nil (bearer tokens only).
+func AuthMiddleware(iamSvc iam.Service, dpop *DPoP) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-			auth := r.Header.Get("Authorization")
-			if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			scheme = strings.ToLower(scheme)
+			if !ok || token == "" || (scheme != "bearer" && scheme != "dpop") {
 				http.Error(w, "missing token", http.StatusUnauthorized)
 				return
 			}
 
-			token := strings.TrimPrefix(auth, "Bearer ")
-
 			subject, err := iamSvc.VerifyAccessToken(r.Context(), token)
 			if err != nil {
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
 
+			if err := dpop.checkResource(r, scheme, token, subject); err != nil {
+				w.Header().Set("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+				http.Error(w, "invalid token binding", http.StatusUnauthorized)
+				return
+			}
+
 			ctx := context.WithValue(r.Context(), subjectKey, subject)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// PolicyMiddleware authorizes requests via the IAM policy engine.
+//
+// requiredScopes (optional) must all be granted to the access token;
+// otherwise the request fails with 403 insufficient_scope (RFC 6750)
+// before the policy engine is consulted.
 func PolicyMiddleware(
 	iamSvc iam.Service,
 	action policy.Action,
 	resource policy.ResourceContext,
+	requiredScopes ...string,
 ) func(http.Handler) http.Handler {
 
 	return func(next http.Handler) http.Handler {
@@ -52,6 +70,17 @@ func PolicyMiddleware(
 				return
 			}
 
+			for _, sc := range requiredScopes {
+				if !slices.Contains(subject.Scopes, sc) {
+					w.Header().Set(
+						"WWW-Authenticate",
+						fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(requiredScopes, " ")),
+					)
+					http.Error(w, "insufficient scope", http.StatusForbidden)
+					return
+				}
+			}
+
 			decision, err := iamSvc.Authorize(
 				r.Context(),
 				subject,
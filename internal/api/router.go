@@ -11,6 +11,9 @@ func NewRouter(
 	auth *Handlers,
 	books *BookHandlers,
 	keyRotationHandler *KeyRotationHandler,
+	jwksHandler *JWKSHandler,
+	introspectionHandler *IntrospectionHandler,
+	tokenHandler *TokenHandler,
 	metricsHandler http.Handler,
 ) http.Handler {
 	r := chi.NewRouter()
@@ -22,6 +25,17 @@ func NewRouter(
 		metricsHandler.ServeHTTP(w, r)
 	})
 
+	// ================================
+	// Key discovery (public)
+	// ================================
+	r.Get("/.well-known/jwks.json", jwksHandler.JWKS)
+
+	// ================================
+	// OAuth (client-authenticated)
+	// ================================
+	r.Post("/oauth/introspect", introspectionHandler.Introspect)
+	r.Post("/oauth/token", tokenHandler.Token)
+
 	r.Route("/api", func(r chi.Router) {
 
 		// Public
@@ -32,7 +46,11 @@ func NewRouter(
 
 		// Protected
 		r.Group(func(r chi.Router) {
-			r.Use(AuthMiddleware(auth.IAM))
+			r.Use(AuthMiddleware(auth.IAM, auth.DPoP))
+
+			r.Get("/sessions", auth.ListSessions)          // GET /api/sessions
+			r.Delete("/sessions/{id}", auth.RevokeSession) // DELETE /api/sessions/{id}
+			r.Post("/logout-all", auth.LogoutAll)          // POST /api/logout-all
 
 			r.Route("/books", func(r chi.Router) {
 				r.Get("/", books.List)          // GET /api/books
@@ -45,14 +63,17 @@ func NewRouter(
 	})
 
 	r.Route("/admin", func(r chi.Router) {
-		r.Use(AuthMiddleware(auth.IAM))
+		r.Use(AuthMiddleware(auth.IAM, auth.DPoP))
 		r.Use(PolicyMiddleware(
 			auth.IAM,
 			policy.Action(policy.Admin),
 			policy.ResourceContext{Type: policy.Admin},
+			ScopeAdminKeys,
 		))
 
+		r.Get("/keys", keyRotationHandler.List)
 		r.Post("/keys/rotate", keyRotationHandler.Rotate)
+		r.Post("/keys/{id}/retire", keyRotationHandler.Retire)
 	})
 
 	return r
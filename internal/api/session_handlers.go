@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/kararnab/authdemo/pkg/iam"
+	"github.com/kararnab/authdemo/pkg/iam/session"
+)
+
+// sessionResp is one entry of GET /api/sessions.
+type sessionResp struct {
+	ID          string    `json:"id"`
+	IP          string    `json:"ip,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	ClientID    string    `json:"client_id,omitempty"`
+	DeviceID    string    `json:"device_id,omitempty"`
+	DeviceBound bool      `json:"device_bound"`
+	LastUsed    time.Time `json:"last_used"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ListSessions ================================
+// GET /api/sessions
+// ================================
+func (h *Handlers) ListSessions(w http.ResponseWriter, r *http.Request) {
+	subject, ok := SubjectFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	list, err := h.IAM.ListSessions(r.Context(), subject.ID)
+	if err != nil {
+		http.Error(w, "failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	out := make([]sessionResp, 0, len(list))
+	for _, s := range list {
+		out = append(out, sessionResp{
+			ID:          s.ID,
+			IP:          s.Attrs[iam.ClientAttrIP],
+			UserAgent:   s.Attrs[iam.ClientAttrUserAgent],
+			ClientID:    s.Attrs[iam.ClientAttrClientID],
+			DeviceID:    s.Attrs[iam.ClientAttrDeviceID],
+			DeviceBound: s.Attrs[session.AttrDeviceKeyThumbprint] != "",
+			LastUsed:    s.LastUsed,
+			ExpiresAt:   s.ExpiresAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+// RevokeSession ================================
+// DELETE /api/sessions/{id}
+// ================================
+func (h *Handlers) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	subject, ok := SubjectFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+
+	err := h.IAM.RevokeSession(r.Context(), subject.ID, id)
+	if errors.Is(err, session.ErrInvalidSession) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll ================================
+// POST /api/logout-all
+// ================================
+func (h *Handlers) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	subject, ok := SubjectFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.IAM.RevokeAll(r.Context(), subject.ID); err != nil {
+		http.Error(w, "failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "logged_out_everywhere",
+	})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam"
+)
+
+// OAuth token endpoint parameters (RFC 8693 §2.1, §3).
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// TokenHandler serves the OAuth 2.0 token endpoint for services.
+//
+// Only token exchange (RFC 8693) is supported: an authenticated client
+// trades the user token it was called with for a token to call another
+// service on the user's behalf, with narrower audience and scopes.
+// Users keep logging in through /api/login.
+type TokenHandler struct {
+	IAM      iam.Service
+	Clients  ClientCredentials
+	Resolver *ClientInfoResolver // caller IP for audit (optional)
+}
+
+// NewTokenHandler creates a new TokenHandler instance.
+func NewTokenHandler(
+	iamSvc iam.Service,
+	clients ClientCredentials,
+	resolver *ClientInfoResolver,
+) *TokenHandler {
+	return &TokenHandler{
+		IAM:      iamSvc,
+		Clients:  clients,
+		Resolver: resolver,
+	}
+}
+
+// tokenResponse is a successful token exchange response (RFC 8693 §2.2.1).
+type tokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Scope           string `json:"scope,omitempty"`
+}
+
+// Token ================================
+// POST /oauth/token
+// ================================
+func (h *TokenHandler) Token(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := h.Clients.Authenticate(r)
+	if !ok {
+		writeInvalidClient(w, "token")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, "invalid_request")
+		return
+	}
+	form := r.PostForm
+
+	if form.Get("grant_type") != GrantTypeTokenExchange {
+		writeOAuthError(w, "unsupported_grant_type")
+		return
+	}
+
+	// Only access tokens are exchanged, for access tokens; the actor
+	// is the authenticated client (actor_token is not supported).
+	if form.Get("subject_token") == "" ||
+		form.Get("subject_token_type") != TokenTypeAccessToken ||
+		form.Get("actor_token") != "" ||
+		(form.Get("requested_token_type") != "" && form.Get("requested_token_type") != TokenTypeAccessToken) {
+		writeOAuthError(w, "invalid_request")
+		return
+	}
+
+	caller := h.Resolver.Resolve(r)
+	caller.ClientID = clientID
+	ctx := iam.WithClient(r.Context(), caller)
+
+	res, err := h.IAM.Exchange(ctx, iam.ExchangeRequest{
+		SubjectToken: form.Get("subject_token"),
+		ActorID:      clientID,
+		Audience:     form["audience"],
+		Scopes:       strings.Fields(form.Get("scope")),
+	})
+	switch {
+	case errors.Is(err, iam.ErrUnauthorizedClient):
+		writeOAuthError(w, "unauthorized_client")
+		return
+	case errors.Is(err, iam.ErrInvalidGrant):
+		writeOAuthError(w, "invalid_grant")
+		return
+	case errors.Is(err, iam.ErrInvalidTarget):
+		writeOAuthError(w, "invalid_target")
+		return
+	case errors.Is(err, iam.ErrInvalidScope):
+		writeOAuthError(w, "invalid_scope")
+		return
+	case err != nil:
+		http.Error(w, "token exchange failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:     res.AccessToken,
+		IssuedTokenType: TokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(time.Until(res.ExpiresAt).Round(time.Second) / time.Second),
+		Scope:           strings.Join(res.Scopes, " "),
+	})
+}
+
+// writeOAuthError answers a rejected token request (RFC 6749 §5.2).
+func writeOAuthError(w http.ResponseWriter, code string) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error": code,
+	})
+}
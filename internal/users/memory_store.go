@@ -8,6 +8,9 @@ import (
 	internalprov "github.com/kararnab/authdemo/pkg/iam/provider/inhouse"
 )
 
+// ErrUserNotFound is returned by lookups of unknown users.
+var ErrUserNotFound = errors.New("users: user not found")
+
 type MemoryUserStore struct {
 	mu    sync.RWMutex
 	users map[string]*internalprov.User
@@ -29,11 +32,27 @@ func (s *MemoryUserStore) GetByUsername(
 
 	u, ok := s.users[username]
 	if !ok {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 	return u, nil
 }
 
+func (s *MemoryUserStore) GetByID(
+	ctx context.Context,
+	id string,
+) (*internalprov.User, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
 func (s *MemoryUserStore) Create(
 	ctx context.Context,
 	user *internalprov.User,
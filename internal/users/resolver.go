@@ -0,0 +1,65 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/kararnab/authdemo/pkg/iam"
+)
+
+// SubjectResolver re-reads subjects from the user store.
+//
+// It satisfies service.SubjectResolver, so role changes made in the
+// store show up in access tokens at the next refresh, and deleted
+// users lose their sessions.
+type SubjectResolver struct {
+	store    *MemoryUserStore
+	provider string // name of the provider backed by store
+}
+
+// NewSubjectResolver creates a resolver for the subjects of provider
+// (the internal provider reading the same store).
+func NewSubjectResolver(store *MemoryUserStore, provider string) *SubjectResolver {
+	return &SubjectResolver{store: store, provider: provider}
+}
+
+// ResolveSubject returns the current roles and attributes of a user.
+//
+// Expected behavior:
+//   - Subjects of other providers (e.g. Google sign-ins) yield
+//     iam.ErrSubjectNotFound
+//   - Users missing from the store yield iam.ErrSubjectDeleted
+//   - Sessions without a recorded provider (created before it was
+//     recorded) are looked up; a miss yields iam.ErrSubjectNotFound
+//     since the subject may belong to another provider
+func (r *SubjectResolver) ResolveSubject(
+	ctx context.Context,
+	provider string,
+	subjectID string,
+) (*iam.Subject, error) {
+
+	if provider != "" && provider != r.provider {
+		return nil, iam.ErrSubjectNotFound
+	}
+
+	u, err := r.store.GetByID(ctx, subjectID)
+	switch {
+	case errors.Is(err, ErrUserNotFound) && provider == "":
+		return nil, iam.ErrSubjectNotFound
+	case errors.Is(err, ErrUserNotFound):
+		return nil, fmt.Errorf("%w: user %s", iam.ErrSubjectDeleted, subjectID)
+	case err != nil:
+		return nil, fmt.Errorf("users: resolve subject: %w", err)
+	}
+
+	// Same shape the internal provider produces at login.
+	return &iam.Subject{
+		ID:    u.ID,
+		Roles: slices.Clone(u.Roles),
+		Attrs: map[string]string{
+			"email": u.Email,
+		},
+	}, nil
+}
@@ -11,9 +11,16 @@ const (
 	EventAuthSuccess        EventType = "auth_success"
 	EventAuthFailure        EventType = "auth_failure"
 	EventTokenRefresh       EventType = "token_refresh"
+	EventRefreshTokenReuse  EventType = "refresh_token_reuse"
 	EventTokenVerifyFailure EventType = "token_verify_failure"
+	EventTokenIntrospect    EventType = "token_introspect"
+	EventTokenRevoked       EventType = "token_revoked"
+	EventTokenExchange      EventType = "token_exchange"
 	EventSessionRevoked     EventType = "session_revoked"
+	EventSessionEvicted     EventType = "session_evicted"
 	EventPolicyDenied       EventType = "policy_denied"
+	EventKeyRotated         EventType = "key_rotated"
+	EventKeyRetired         EventType = "key_retired"
 )
 
 // Event represents a single audit log entry.
@@ -2,16 +2,39 @@ package iam
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/kararnab/authdemo/pkg/iam/policy"
 )
 
+// ErrSubjectNotFound is returned by subject lookups for unknown subjects
+// (e.g. identities that only exist at an external provider).
+var ErrSubjectNotFound = errors.New("iam: subject not found")
+
+// ErrSubjectDeleted is returned by subject lookups for subjects the
+// lookup manages but that no longer exist (e.g. a deleted user):
+// their sessions must end.
+var ErrSubjectDeleted = errors.New("iam: subject no longer exists")
+
 // Subject represents an authenticated principal in the system.
 // This is the ONLY identity shape business code should see.
 type Subject struct {
 	ID    string            // canonical internal user ID
 	Roles []string          // coarse-grained roles (optional)
 	Attrs map[string]string // extensible attributes (org, tier, tier_level, etc)
+
+	// Scopes granted to the presented access token (empty at login).
+	Scopes []string
+
+	// Actors is the delegation chain of an exchanged token
+	// (current actor first); empty when the subject acts itself.
+	Actors []string
+
+	// Confirmation is the DPoP key thumbprint (cnf.jkt) the presented
+	// access token is bound to; empty for bearer tokens. Transports
+	// MUST verify a matching DPoP proof when it is set.
+	Confirmation string
 }
 
 // AuthResult is returned after a successful authentication.
@@ -22,6 +45,40 @@ type AuthResult struct {
 	Subject      Subject
 }
 
+// RefreshResult is returned after a successful refresh.
+//
+// The presented refresh token is no longer valid: clients MUST
+// replace it with RefreshToken.
+type RefreshResult struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// TokenInfo describes an access token (token introspection, RFC 7662).
+//
+// Inactive tokens (invalid, expired, revoked, or meant for another
+// audience) carry no other information.
+type TokenInfo struct {
+	Active    bool
+	Subject   Subject
+	TokenID   string // jti
+	SessionID string // sid (empty for tokens not tied to a login)
+	Audience  []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// SessionInfo describes one login of a subject (a refresh-token session).
+//
+// ID is stable across refresh token rotation and is used to
+// revoke the session; it is not a credential.
+type SessionInfo struct {
+	ID        string
+	LastUsed  time.Time
+	ExpiresAt time.Time
+	Attrs     map[string]string // ClientAttr* keys (recorded at login)
+}
+
 // Service defines the IAM capability exposed to the application.
 // This interface intentionally hides providers, tokens, sessions,
 // and storage so IAM can later be:
@@ -43,7 +100,6 @@ type Service interface {
 	// TODO:
 	//   - MFA / step-up authentication
 	//   - Risk-based auth
-	//   - Device binding
 	Authenticate(
 		ctx context.Context,
 		req AuthRequest,
@@ -62,17 +118,15 @@ type Service interface {
 	// Expected flow:
 	//   - Validate refresh token (stateful)
 	//   - Check session validity / revocation
-	//   - Rotate refresh token (optional)
+	//   - Rotate refresh token (reuse revokes the whole session family)
 	//   - Issue new access token
 	//
 	// TODO:
-	//   - Refresh token rotation
-	//   - Reuse detection
 	//   - Session invalidation hooks
 	Refresh(
 		ctx context.Context,
 		refreshToken string,
-	) (string, error)
+	) (*RefreshResult, error)
 
 	// VerifyAccessToken validates an access token and extracts the subject.
 	//
@@ -86,11 +140,74 @@ type Service interface {
 		accessToken string,
 	) (*Subject, error)
 
-	// Revoke invalidates a refresh token (session).
+	// Introspect reports whether an access token is active and,
+	// if so, what it grants (RFC 7662).
+	//
+	// Invalid tokens are NOT an error: they are reported inactive.
+	// The caller (resource server) is taken from ClientFromContext.
+	Introspect(
+		ctx context.Context,
+		accessToken string,
+	) (*TokenInfo, error)
+
+	// Exchange derives a token for another audience with reduced
+	// scopes, acting on behalf of the subject (RFC 8693).
+	//
+	// Expected behavior:
+	//   - Verify the subject token (active, not sender-constrained)
+	//   - Check the actor may exchange for the requested audience / scopes
+	//   - Never widen scopes or lifetime; keep the session (sid)
+	//   - Record the actor in the "act" claim
+	Exchange(
+		ctx context.Context,
+		req ExchangeRequest,
+	) (*ExchangeResult, error)
+
+	// Revoke invalidates a refresh token (session) and, with a
+	// denylist configured, the access tokens issued for it.
 	Revoke(
 		ctx context.Context,
 		refreshToken string,
 	) error
+
+	// RevokeAccessToken invalidates one access token (by jti)
+	// until it expires. Requires a denylist.
+	RevokeAccessToken(
+		ctx context.Context,
+		accessToken string,
+	) error
+
+	// ListSessions returns the active sessions of a subject.
+	ListSessions(
+		ctx context.Context,
+		subjectID string,
+	) ([]SessionInfo, error)
+
+	// RevokeSession ends one of the subject's sessions by SessionInfo.ID
+	// and, with a denylist configured, the access tokens issued for it.
+	RevokeSession(
+		ctx context.Context,
+		subjectID string,
+		sessionID string,
+	) error
+
+	// RevokeAll ends every session of a subject ("logout everywhere").
+	//
+	// With a denylist configured, outstanding access tokens of those
+	// sessions are denied by sid until they would have expired; this
+	// covers every format issued here, opaque tokens included.
+	//
+	// Exceptions (valid until they expire):
+	//   - without a denylist, all access tokens
+	//   - tokens without a sid (e.g. issued before sids were added)
+	//   - tokens of trusted external issuers, which have no session here
+	//
+	// Other replicas sharing the denylist store apply the revocation
+	// at their next denylist refresh.
+	RevokeAll(
+		ctx context.Context,
+		subjectID string,
+	) error
 }
 
 // AuthRequest represents a generic authentication attempt.
@@ -108,4 +225,18 @@ type Service interface {
 type AuthRequest struct {
 	Provider string            // "google", "keycloak", "internal", etc
 	Params   map[string]string // provider-specific inputs
+
+	// Client is recorded on the created session
+	// and attached to audit events.
+	Client ClientInfo
+
+	// DeviceKey optionally binds the session to a client-held key
+	// (public JWK, JSON). Refreshing then requires a proof signed
+	// by the matching private key.
+	DeviceKey string
+
+	// DPoPThumbprint binds the issued access token to a DPoP key
+	// (RFC 9449). The transport verifies the DPoP proof and passes
+	// the key thumbprint; empty issues a bearer token.
+	DPoPThumbprint string
 }
@@ -0,0 +1,70 @@
+package iam
+
+import "context"
+
+// ClientInfo describes the client behind a request.
+//
+// It is recorded on sessions at login (Session.Attrs) and attached
+// to audit events. All fields are informational: none of them is
+// authenticated and they must never drive authorization.
+type ClientInfo struct {
+	IP        string // remote IP (proxy-aware, see transport layer)
+	UserAgent string
+	ClientID  string // calling application (web, ios, cli, ...)
+	DeviceID  string // client-generated, stable per install
+}
+
+// Client attribute keys, shared by Session.Attrs and audit events.
+const (
+	ClientAttrIP        = "ip"
+	ClientAttrUserAgent = "user_agent"
+	ClientAttrClientID  = "client_id"
+	ClientAttrDeviceID  = "device_id"
+)
+
+// Attrs returns the non-empty fields as attributes.
+func (c ClientInfo) Attrs() map[string]string {
+	attrs := make(map[string]string, 4)
+	for k, v := range map[string]string{
+		ClientAttrIP:        c.IP,
+		ClientAttrUserAgent: c.UserAgent,
+		ClientAttrClientID:  c.ClientID,
+		ClientAttrDeviceID:  c.DeviceID,
+	} {
+		if v != "" {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+type clientCtxKey struct{}
+
+// WithClient attaches client metadata to ctx.
+//
+// Used for calls that have no request struct to carry it
+// (e.g. Refresh, Revoke).
+func WithClient(ctx context.Context, c ClientInfo) context.Context {
+	return context.WithValue(ctx, clientCtxKey{}, c)
+}
+
+// ClientFromContext returns the client metadata attached by WithClient.
+func ClientFromContext(ctx context.Context) (ClientInfo, bool) {
+	c, ok := ctx.Value(clientCtxKey{}).(ClientInfo)
+	return c, ok
+}
+
+type dpopCtxKey struct{}
+
+// WithDPoPThumbprint requests DPoP-bound access tokens for calls
+// without a request struct (Refresh). The transport must have
+// verified the DPoP proof for this key.
+func WithDPoPThumbprint(ctx context.Context, jkt string) context.Context {
+	return context.WithValue(ctx, dpopCtxKey{}, jkt)
+}
+
+// DPoPThumbprintFromContext returns the key set by WithDPoPThumbprint.
+func DPoPThumbprintFromContext(ctx context.Context) string {
+	jkt, _ := ctx.Value(dpopCtxKey{}).(string)
+	return jkt
+}
@@ -0,0 +1,49 @@
+package iam
+
+import (
+	"errors"
+	"time"
+)
+
+// Token exchange errors (RFC 8693 §2.2.2 / RFC 6749 §5.2 codes).
+var (
+	// ErrInvalidGrant: the subject token is invalid, expired,
+	// revoked or sender-constrained.
+	ErrInvalidGrant = errors.New("iam: invalid subject token")
+
+	// ErrUnauthorizedClient: the actor may not exchange tokens.
+	ErrUnauthorizedClient = errors.New("iam: client may not exchange tokens")
+
+	// ErrInvalidTarget: the requested audience is not allowed.
+	ErrInvalidTarget = errors.New("iam: audience not allowed")
+
+	// ErrInvalidScope: a requested scope is not held by the subject
+	// token or not allowed for the actor.
+	ErrInvalidScope = errors.New("iam: scope not allowed")
+)
+
+// ExchangeRequest asks for a token to call another service on
+// behalf of the subject of SubjectToken (OAuth 2.0 Token Exchange).
+type ExchangeRequest struct {
+	SubjectToken string // access token of the subject (user)
+
+	// ActorID is the authenticated client performing the exchange;
+	// it is recorded in the "act" claim of the new token.
+	ActorID string
+
+	// Audience requested for the new token; may be empty if the
+	// actor is allowed exactly one audience.
+	Audience []string
+
+	// Scopes requested; empty means every scope the subject token
+	// holds that the actor may request.
+	Scopes []string
+}
+
+// ExchangeResult is the down-scoped token.
+type ExchangeResult struct {
+	AccessToken string
+	Audience    []string
+	Scopes      []string
+	ExpiresAt   time.Time
+}
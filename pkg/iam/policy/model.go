@@ -29,6 +29,7 @@ type SubjectContext struct {
 	SubjectID string
 	Roles     []string
 	Attrs     map[string]string
+	Scopes    []string // granted to the access token
 }
 
 // ResourceContext represents the target of an authorization decision.
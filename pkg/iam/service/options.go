@@ -1,11 +1,16 @@
 package service
 
 import (
+	"context"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam"
 	"github.com/kararnab/authdemo/pkg/iam/audit"
 	"github.com/kararnab/authdemo/pkg/iam/policy"
 	"github.com/kararnab/authdemo/pkg/iam/provider"
 	"github.com/kararnab/authdemo/pkg/iam/session"
 	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/revocation"
 	"github.com/kararnab/authdemo/pkg/metrics"
 )
 
@@ -22,7 +27,64 @@ type Options struct {
 	TokenIssuer   token.Issuer
 	TokenVerifier token.Verifier
 
+	// Denylist (optional) revokes access tokens together with their
+	// session (logout, logout everywhere, refresh token reuse,
+	// eviction) instead of letting them run until exp.
+	// AccessTokenTTL (the issuer's token lifetime) is then required:
+	// entries are kept that long.
+	Denylist       *revocation.Denylist
+	AccessTokenTTL time.Duration
+
+	// Audience ("aud") of issued access tokens. VerifyAccessToken
+	// only accepts tokens for (one of) these audiences, so tokens
+	// exchanged for other services are not accepted here. Token
+	// verifiers are then configured without an audience.
+	Audience []string
+
+	// Scopes granted to issued access tokens: DefaultScopes for
+	// everyone plus RoleScopes of each of the subject's roles.
+	DefaultScopes []string
+	RoleScopes    map[string][]string
+
+	// ExchangeGrants lists, per client ID, what that client may obtain
+	// through token exchange. Clients not listed may not exchange.
+	ExchangeGrants map[string]ExchangeGrant
+
 	PolicyEngine policy.Engine
 	AuditLogger  audit.Logger
 	Metrics      metrics.IAMMetrics
+
+	// SubjectResolver (optional) re-reads roles and attributes on refresh,
+	// so role changes take effect at the next refresh.
+	// When nil, the snapshot taken at login is used.
+	SubjectResolver SubjectResolver
+}
+
+// SubjectResolver looks up the current roles and attributes of a subject.
+//
+// provider is the identity provider of the login (session.AttrProvider;
+// empty for sessions created before it was recorded).
+//
+// Expected behavior:
+//   - Return iam.ErrSubjectNotFound for subjects of providers it does not
+//     manage: the service falls back to the session snapshot
+//   - Return iam.ErrSubjectDeleted for managed subjects that no longer
+//     exist: the refresh fails and the session family is revoked
+//   - Return other errors (e.g. store I/O) as is: the refresh fails
+type SubjectResolver interface {
+	ResolveSubject(
+		ctx context.Context,
+		provider string,
+		subjectID string,
+	) (*iam.Subject, error)
+}
+
+// ExchangeGrant is what one client may request by token exchange.
+//
+// Exchanged tokens hold the intersection of the subject token's
+// scopes and Scopes: exchange never adds permissions.
+type ExchangeGrant struct {
+	Audiences []string      // audiences the client may target
+	Scopes    []string      // scopes the client may request
+	MaxTTL    time.Duration // lifetime cap (default: the subject token's)
 }
@@ -3,11 +3,17 @@ package service
 import (
 	"context"
 	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/kararnab/authdemo/pkg/iam"
 	"github.com/kararnab/authdemo/pkg/iam/audit"
 	"github.com/kararnab/authdemo/pkg/iam/policy"
+	"github.com/kararnab/authdemo/pkg/iam/session"
 	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/log"
 )
 
 // Service is the default IAM service implementation.
@@ -41,6 +47,9 @@ func New(opts Options) (*Service, error) {
 	if opts.AuditLogger == nil {
 		return nil, errors.New("iam: audit logger is required")
 	}
+	if opts.Denylist != nil && opts.AccessTokenTTL <= 0 {
+		return nil, errors.New("iam: access token TTL is required with a denylist")
+	}
 
 	return &Service{opts: opts}, nil
 }
@@ -48,28 +57,86 @@ func New(opts Options) (*Service, error) {
 func (s *Service) Refresh(
 	ctx context.Context,
 	refreshToken string,
-) (string, error) {
+) (*iam.RefreshResult, error) {
+
+	client, _ := iam.ClientFromContext(ctx)
 
-	sess, err := s.opts.SessionManager.Validate(ctx, refreshToken)
+	// Sessions of DPoP-bound logins only rotate with a proof by their key.
+	ctx = session.WithDPoPThumbprint(ctx, iam.DPoPThumbprintFromContext(ctx))
+
+	// Rotation is the "touch": the successor is marked as used now
+	// and (with sliding expiration) expires later than its parent.
+	sess, err := s.opts.SessionManager.Rotate(ctx, refreshToken)
 	if err != nil {
 		s.opts.Metrics.TokenRefreshFailure()
+
+		var reuse *session.ReuseError
+		if errors.As(err, &reuse) {
+			s.opts.Metrics.RefreshTokenReuse()
+			s.revokeAccessTokens(ctx, reuse.FamilyID)
+			_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+				Type:      audit.EventRefreshTokenReuse,
+				SubjectID: reuse.SubjectID,
+				Message:   "refresh token reuse detected, session family revoked",
+				Attrs: clientAttrs(client, map[string]string{
+					"family_id": reuse.FamilyID,
+				}),
+			})
+			return nil, err
+		}
+
 		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
 			Type:    audit.EventTokenRefresh,
 			Message: "refresh failed",
-			Attrs: map[string]string{
+			Attrs: clientAttrs(client, map[string]string{
 				"reason": err.Error(),
-			},
+			}),
 		})
-		return "", err
+		return nil, err
 	}
 
-	claims := token.Claims{
-		SubjectID: sess.SubjectID,
+	subject, err := s.resolveSubject(ctx, sess)
+	if err != nil {
+		reason := "subject_resolution_failed"
+		if errors.Is(err, iam.ErrSubjectDeleted) {
+			// The login outlived its user: end it, not just this refresh.
+			reason = "subject_deleted"
+			if rerr := s.opts.SessionManager.RevokeSession(ctx, sess.SubjectID, sess.FamilyID); rerr != nil {
+				log.Warn(
+					"session revocation for deleted subject failed",
+					log.F("error", rerr, log.RedactNone),
+				)
+			}
+			s.revokeAccessTokens(ctx, sess.FamilyID)
+		}
+
+		s.opts.Metrics.TokenRefreshFailure()
+		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+			Type:      audit.EventTokenRefresh,
+			SubjectID: sess.SubjectID,
+			Message:   "refresh failed",
+			Attrs: clientAttrs(client, map[string]string{
+				"reason":    reason,
+				"family_id": sess.FamilyID,
+			}),
+		})
+		return nil, err
 	}
 
-	accessToken, err := s.opts.TokenIssuer.Issue(ctx, claims)
+	accessToken, err := s.opts.TokenIssuer.Issue(
+		ctx,
+		token.Claims{
+			SubjectID:    subject.ID,
+			Roles:        subject.Roles,
+			Attrs:        subject.Attrs,
+			Audience:     s.opts.Audience,
+			Scopes:       s.grantScopes(subject.Roles),
+			SessionID:    sess.FamilyID,
+			Confirmation: iam.DPoPThumbprintFromContext(ctx),
+		},
+	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	s.opts.Metrics.TokenRefreshSuccess()
@@ -77,9 +144,92 @@ func (s *Service) Refresh(
 		Type:      audit.EventTokenRefresh,
 		SubjectID: sess.SubjectID,
 		Message:   "token refreshed",
+		Attrs: clientAttrs(client, map[string]string{
+			"family_id": sess.FamilyID,
+		}),
 	})
 
-	return accessToken, nil
+	return &iam.RefreshResult{
+		AccessToken:  accessToken,
+		RefreshToken: sess.Token,
+	}, nil
+}
+
+// resolveSubject returns the subject a refreshed access token is issued for.
+//
+// The optional SubjectResolver wins (fresh roles); otherwise, or for
+// subjects of providers it does not manage, the login-time snapshot
+// is used. Resolver errors (deleted subject, store failure) fail the
+// refresh: a stale snapshot must not outlive its user.
+func (s *Service) resolveSubject(
+	ctx context.Context,
+	sess *session.Session,
+) (*iam.Subject, error) {
+
+	if s.opts.SubjectResolver != nil {
+		subject, err := s.opts.SubjectResolver.ResolveSubject(ctx, sess.Attrs[session.AttrProvider], sess.SubjectID)
+		if err == nil {
+			return subject, nil
+		}
+		if !errors.Is(err, iam.ErrSubjectNotFound) {
+			return nil, err
+		}
+	}
+
+	return &iam.Subject{
+		ID:    sess.SubjectID,
+		Roles: sess.Roles,
+		Attrs: sess.SubjectAttrs,
+	}, nil
+}
+
+// grantScopes returns the scopes of an access token for a subject
+// with the given roles (deduplicated, in configuration order).
+func (s *Service) grantScopes(roles []string) []string {
+	var scopes []string
+	add := func(list []string) {
+		for _, sc := range list {
+			if !slices.Contains(scopes, sc) {
+				scopes = append(scopes, sc)
+			}
+		}
+	}
+
+	add(s.opts.DefaultScopes)
+	for _, role := range roles {
+		add(s.opts.RoleScopes[role])
+	}
+	return scopes
+}
+
+// clientAttrs merges client metadata into audit attributes
+// (event-specific attrs win on key collisions).
+func clientAttrs(
+	client iam.ClientInfo,
+	attrs map[string]string,
+) map[string]string {
+
+	out := client.Attrs()
+	maps.Copy(out, attrs)
+	return out
+}
+
+// sessionAttrs is the metadata recorded on a new session: client info
+// and the provider the subject logged in with.
+func sessionAttrs(req iam.AuthRequest) map[string]string {
+	attrs := req.Client.Attrs()
+	attrs[session.AttrProvider] = req.Provider
+	return attrs
+}
+
+// clientFromAttrs reads back client metadata recorded on a session.
+func clientFromAttrs(attrs map[string]string) iam.ClientInfo {
+	return iam.ClientInfo{
+		IP:        attrs[iam.ClientAttrIP],
+		UserAgent: attrs[iam.ClientAttrUserAgent],
+		ClientID:  attrs[iam.ClientAttrClientID],
+		DeviceID:  attrs[iam.ClientAttrDeviceID],
+	}
 }
 
 func (s *Service) Authenticate(
@@ -94,6 +244,7 @@ func (s *Service) Authenticate(
 			Type:     audit.EventAuthFailure,
 			Provider: req.Provider,
 			Message:  "unknown auth provider",
+			Attrs:    clientAttrs(req.Client, nil),
 		})
 		return nil, errors.New("iam: unknown provider")
 	}
@@ -105,9 +256,9 @@ func (s *Service) Authenticate(
 			Type:     audit.EventAuthFailure,
 			Provider: req.Provider,
 			Message:  "authentication failed",
-			Attrs: map[string]string{
+			Attrs: clientAttrs(req.Client, map[string]string{
 				"reason": "provider_auth_failed",
-			},
+			}),
 		})
 		return nil, err
 	}
@@ -118,45 +269,103 @@ func (s *Service) Authenticate(
 		Attrs: identity.Attrs,
 	}
 
-	session, err := s.opts.SessionManager.Create(ctx, subject.ID, nil)
+	created, err := s.opts.SessionManager.Create(ctx, session.CreateRequest{
+		SubjectID:    subject.ID,
+		Roles:        subject.Roles,
+		SubjectAttrs: subject.Attrs,
+		Attrs:        sessionAttrs(req),
+		DeviceKey:    req.DeviceKey,
+
+		DPoPThumbprint: req.DPoPThumbprint,
+	})
+	if created != nil {
+		s.auditEvictions(ctx, subject.ID, created.Evicted)
+	}
 	if err != nil {
+		reason := "session_creation_failed"
+		switch {
+		case errors.Is(err, session.ErrSessionLimitReached):
+			reason = "session_limit_reached"
+			s.opts.Metrics.SessionLimitRejected()
+		case errors.Is(err, session.ErrInvalidDeviceKey):
+			reason = "invalid_device_key"
+		}
+
 		s.opts.Metrics.AuthFailure()
 		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
 			Type:      audit.EventAuthFailure,
 			SubjectID: subject.ID,
 			Provider:  req.Provider,
 			Message:   "session creation failed",
+			Attrs: clientAttrs(req.Client, map[string]string{
+				"reason": reason,
+			}),
 		})
 		return nil, err
 	}
+	sess := created.Session
 
 	accessToken, err := s.opts.TokenIssuer.Issue(
 		ctx,
 		token.Claims{
-			SubjectID: subject.ID,
-			Roles:     subject.Roles,
-			Attrs:     subject.Attrs,
+			SubjectID:    subject.ID,
+			Roles:        subject.Roles,
+			Attrs:        subject.Attrs,
+			Audience:     s.opts.Audience,
+			Scopes:       s.grantScopes(subject.Roles),
+			SessionID:    sess.FamilyID,
+			Confirmation: req.DPoPThumbprint,
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	successAttrs := map[string]string{
+		"family_id": sess.FamilyID,
+	}
+	if jkt := sess.Attrs[session.AttrDeviceKeyThumbprint]; jkt != "" {
+		successAttrs["device_jkt"] = jkt
+	}
+
 	s.opts.Metrics.AuthSuccess()
 	_ = s.opts.AuditLogger.Log(ctx, audit.Event{
 		Type:      audit.EventAuthSuccess,
 		SubjectID: subject.ID,
 		Provider:  req.Provider,
 		Message:   "authentication successful",
+		Attrs:     clientAttrs(req.Client, successAttrs),
 	})
 
 	return &iam.AuthResult{
 		AccessToken:  accessToken,
-		RefreshToken: session.ID,
+		RefreshToken: sess.Token,
 		Subject:      subject,
 	}, nil
 }
 
+// auditEvictions records sessions ended by concurrent session limits.
+func (s *Service) auditEvictions(
+	ctx context.Context,
+	subjectID string,
+	evicted []session.EvictedSession,
+) {
+
+	for _, e := range evicted {
+		s.opts.Metrics.SessionEvicted()
+		s.revokeAccessTokens(ctx, e.Session.FamilyID)
+		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+			Type:      audit.EventSessionEvicted,
+			SubjectID: subjectID,
+			Message:   "session evicted by concurrent session limit",
+			Attrs: clientAttrs(clientFromAttrs(e.Session.Attrs), map[string]string{
+				"family_id": e.Session.FamilyID,
+				"reason":    e.Reason,
+			}),
+		})
+	}
+}
+
 func (s *Service) Authorize(
 	ctx context.Context,
 	subject *iam.Subject,
@@ -170,6 +379,7 @@ func (s *Service) Authorize(
 			SubjectID: subject.ID,
 			Roles:     subject.Roles,
 			Attrs:     subject.Attrs,
+			Scopes:    subject.Scopes,
 		},
 		action,
 		resource,
@@ -206,23 +416,229 @@ func (s *Service) VerifyAccessToken(
 		return nil, err
 	}
 
+	if !s.acceptsAudience(claims) {
+		s.opts.Metrics.TokenVerifyFailure()
+		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+			Type:      audit.EventTokenVerifyFailure,
+			SubjectID: claims.SubjectID,
+			Message:   "access token for another audience presented",
+			Attrs: map[string]string{
+				"jti": claims.ID,
+			},
+		})
+		return nil, errors.New("iam: access token audience mismatch")
+	}
+
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		s.opts.Metrics.TokenVerifyFailure()
+		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+			Type:      audit.EventTokenVerifyFailure,
+			SubjectID: claims.SubjectID,
+			Message:   "revoked access token presented",
+			Attrs: map[string]string{
+				"jti": claims.ID,
+			},
+		})
+		return nil, err
+	}
+
 	s.opts.Metrics.TokenVerifySuccess()
 
 	subject := &iam.Subject{
-		ID:    claims.SubjectID,
-		Roles: claims.Roles,
-		Attrs: claims.Attrs,
+		ID:           claims.SubjectID,
+		Roles:        claims.Roles,
+		Attrs:        claims.Attrs,
+		Scopes:       claims.Scopes,
+		Actors:       claims.Actors,
+		Confirmation: claims.Confirmation,
 	}
 
 	return subject, nil
 }
 
+func (s *Service) Introspect(
+	ctx context.Context,
+	accessToken string,
+) (*iam.TokenInfo, error) {
+
+	client, _ := iam.ClientFromContext(ctx)
+
+	claims, err := s.opts.TokenVerifier.Verify(ctx, accessToken)
+	if err == nil {
+		err = s.checkRevoked(ctx, claims)
+	}
+	if err != nil {
+		s.opts.Metrics.TokenVerifyFailure()
+		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+			Type:    audit.EventTokenIntrospect,
+			Message: "inactive token introspected",
+			Attrs:   clientAttrs(client, nil),
+		})
+		return &iam.TokenInfo{Active: false}, nil
+	}
+
+	s.opts.Metrics.TokenVerifySuccess()
+	_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+		Type:      audit.EventTokenIntrospect,
+		SubjectID: claims.SubjectID,
+		Message:   "active token introspected",
+		Attrs: clientAttrs(client, map[string]string{
+			"jti": claims.ID,
+		}),
+	})
+
+	return &iam.TokenInfo{
+		Active: true,
+		Subject: iam.Subject{
+			ID:           claims.SubjectID,
+			Roles:        claims.Roles,
+			Attrs:        claims.Attrs,
+			Scopes:       claims.Scopes,
+			Actors:       claims.Actors,
+			Confirmation: claims.Confirmation,
+		},
+		TokenID:   claims.ID,
+		SessionID: claims.SessionID,
+		Audience:  claims.Audience,
+		IssuedAt:  claims.IssuedAt,
+		ExpiresAt: claims.ExpiresAt,
+	}, nil
+}
+
+// Exchange implements iam.Service (RFC 8693 token exchange).
+//
+// The subject token may be for any audience (a service exchanges
+// the token it was called with); the new token is only for the
+// requested audience, never outlives the subject token and keeps its
+// session, so logging out revokes exchanged tokens too.
+func (s *Service) Exchange(
+	ctx context.Context,
+	req iam.ExchangeRequest,
+) (*iam.ExchangeResult, error) {
+
+	client, _ := iam.ClientFromContext(ctx)
+
+	res, subjectID, err := s.exchange(ctx, req)
+	if err != nil {
+		s.opts.Metrics.TokenExchangeFailure()
+		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+			Type:      audit.EventTokenExchange,
+			SubjectID: subjectID,
+			Message:   "token exchange rejected",
+			Attrs: clientAttrs(client, map[string]string{
+				"actor": req.ActorID,
+				"error": err.Error(),
+			}),
+		})
+		return nil, err
+	}
+
+	s.opts.Metrics.TokenExchangeSuccess()
+	_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+		Type:      audit.EventTokenExchange,
+		SubjectID: subjectID,
+		Message:   "token exchanged",
+		Attrs: clientAttrs(client, map[string]string{
+			"actor":    req.ActorID,
+			"audience": strings.Join(res.Audience, " "),
+			"scope":    strings.Join(res.Scopes, " "),
+		}),
+	})
+
+	return res, nil
+}
+
+func (s *Service) exchange(
+	ctx context.Context,
+	req iam.ExchangeRequest,
+) (*iam.ExchangeResult, string, error) {
+
+	grant, ok := s.opts.ExchangeGrants[req.ActorID]
+	if req.ActorID == "" || !ok {
+		return nil, "", iam.ErrUnauthorizedClient
+	}
+
+	claims, err := s.opts.TokenVerifier.Verify(ctx, req.SubjectToken)
+	if err != nil {
+		return nil, "", iam.ErrInvalidGrant
+	}
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return nil, claims.SubjectID, iam.ErrInvalidGrant
+	}
+	// A sender-constrained token proves possession by its holder;
+	// another party presenting it is exactly what DPoP prevents.
+	if claims.Confirmation != "" {
+		return nil, claims.SubjectID, iam.ErrInvalidGrant
+	}
+
+	audience := req.Audience
+	if len(audience) == 0 && len(grant.Audiences) == 1 {
+		audience = grant.Audiences
+	}
+	if len(audience) == 0 {
+		return nil, claims.SubjectID, iam.ErrInvalidTarget
+	}
+	for _, aud := range audience {
+		if !slices.Contains(grant.Audiences, aud) {
+			return nil, claims.SubjectID, iam.ErrInvalidTarget
+		}
+	}
+
+	// Never more than the subject token holds, nor than the actor may ask.
+	var allowed []string
+	for _, sc := range claims.Scopes {
+		if slices.Contains(grant.Scopes, sc) {
+			allowed = append(allowed, sc)
+		}
+	}
+	scopes := allowed
+	if len(req.Scopes) > 0 {
+		for _, sc := range req.Scopes {
+			if !slices.Contains(allowed, sc) {
+				return nil, claims.SubjectID, iam.ErrInvalidScope
+			}
+		}
+		scopes = req.Scopes
+	}
+
+	expiresAt := claims.ExpiresAt
+	if grant.MaxTTL > 0 {
+		if limit := time.Now().Add(grant.MaxTTL); limit.Before(expiresAt) {
+			expiresAt = limit
+		}
+	}
+
+	issued := token.Claims{
+		SubjectID: claims.SubjectID,
+		Roles:     claims.Roles,
+		Attrs:     claims.Attrs,
+		Audience:  audience,
+		Scopes:    scopes,
+		Actors:    append([]string{req.ActorID}, claims.Actors...),
+		SessionID: claims.SessionID,
+		ExpiresAt: expiresAt,
+	}
+
+	accessToken, err := s.opts.TokenIssuer.Issue(ctx, issued)
+	if err != nil {
+		return nil, claims.SubjectID, err
+	}
+
+	return &iam.ExchangeResult{
+		AccessToken: accessToken,
+		Audience:    audience,
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+	}, claims.SubjectID, nil
+}
+
 func (s *Service) Revoke(
 	ctx context.Context,
 	refreshToken string,
 ) error {
 
-	if err := s.opts.SessionManager.Revoke(ctx, refreshToken); err != nil {
+	sess, err := s.opts.SessionManager.Revoke(ctx, refreshToken)
+	if err != nil {
 		s.opts.Metrics.SessionRevokeFailure()
 		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
 			Type:    audit.EventSessionRevoked,
@@ -231,11 +647,192 @@ func (s *Service) Revoke(
 		return err
 	}
 
+	s.revokeAccessTokens(ctx, sess.FamilyID)
+
+	s.opts.Metrics.SessionRevokeSuccess()
+	_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+		Type:      audit.EventSessionRevoked,
+		SubjectID: sess.SubjectID,
+		Message:   "session revoked",
+		Attrs: map[string]string{
+			"family_id": sess.FamilyID,
+		},
+	})
+
+	return nil
+}
+
+func (s *Service) RevokeAccessToken(
+	ctx context.Context,
+	accessToken string,
+) error {
+
+	if s.opts.Denylist == nil {
+		return errors.New("iam: access token revocation is not configured")
+	}
+
+	claims, err := s.opts.TokenVerifier.Verify(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	if claims.ID == "" {
+		return errors.New("iam: access token has no jti")
+	}
+
+	if err := s.opts.Denylist.RevokeToken(ctx, claims.ID, claims.ExpiresAt); err != nil {
+		return err
+	}
+
+	_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+		Type:      audit.EventTokenRevoked,
+		SubjectID: claims.SubjectID,
+		Message:   "access token revoked",
+		Attrs: map[string]string{
+			"jti": claims.ID,
+		},
+	})
+
+	return nil
+}
+
+func (s *Service) ListSessions(
+	ctx context.Context,
+	subjectID string,
+) ([]iam.SessionInfo, error) {
+
+	sessions, err := s.opts.SessionManager.ListSessions(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]iam.SessionInfo, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, iam.SessionInfo{
+			ID:        sess.FamilyID, // stable across rotation
+			LastUsed:  sess.LastUsed,
+			ExpiresAt: sess.ExpiresAt,
+			Attrs:     sess.Attrs,
+		})
+	}
+
+	return out, nil
+}
+
+func (s *Service) RevokeSession(
+	ctx context.Context,
+	subjectID string,
+	sessionID string,
+) error {
+
+	if err := s.opts.SessionManager.RevokeSession(ctx, subjectID, sessionID); err != nil {
+		s.opts.Metrics.SessionRevokeFailure()
+		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+			Type:      audit.EventSessionRevoked,
+			SubjectID: subjectID,
+			Message:   "session revoke failed",
+		})
+		return err
+	}
+
+	s.revokeAccessTokens(ctx, sessionID)
+
 	s.opts.Metrics.SessionRevokeSuccess()
 	_ = s.opts.AuditLogger.Log(ctx, audit.Event{
-		Type:    audit.EventSessionRevoked,
-		Message: "session revoked",
+		Type:      audit.EventSessionRevoked,
+		SubjectID: subjectID,
+		Message:   "session revoked",
+		Attrs: map[string]string{
+			"family_id": sessionID,
+		},
 	})
 
 	return nil
 }
+
+func (s *Service) RevokeAll(
+	ctx context.Context,
+	subjectID string,
+) error {
+
+	families, err := s.opts.SessionManager.RevokeAll(ctx, subjectID)
+	if err != nil {
+		s.opts.Metrics.SessionRevokeFailure()
+		_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+			Type:      audit.EventSessionRevoked,
+			SubjectID: subjectID,
+			Message:   "revoke all sessions failed",
+		})
+		return err
+	}
+
+	s.revokeAccessTokens(ctx, families...)
+
+	s.opts.Metrics.SessionRevokeSuccess()
+	_ = s.opts.AuditLogger.Log(ctx, audit.Event{
+		Type:      audit.EventSessionRevoked,
+		SubjectID: subjectID,
+		Message:   "all sessions revoked",
+	})
+
+	return nil
+}
+
+// revokeAccessTokens denies the access tokens issued for the given
+// sessions (sid) until they have expired anyway.
+//
+// Best effort: the sessions are already gone, so a denylist failure
+// only leaves their access tokens valid until exp (logged).
+func (s *Service) revokeAccessTokens(
+	ctx context.Context,
+	familyIDs ...string,
+) {
+
+	if s.opts.Denylist == nil {
+		return
+	}
+
+	exp := time.Now().Add(s.opts.AccessTokenTTL)
+	for _, id := range familyIDs {
+		if err := s.opts.Denylist.RevokeSession(ctx, id, exp); err != nil {
+			log.Warn(
+				"access token denylist update failed",
+				log.F("error", err, log.RedactNone),
+			)
+		}
+	}
+}
+
+// acceptsAudience reports whether claims are for this service
+// (any audience when Options.Audience is empty).
+func (s *Service) acceptsAudience(claims *token.Claims) bool {
+	if len(s.opts.Audience) == 0 {
+		return true
+	}
+	for _, aud := range s.opts.Audience {
+		if claims.HasAudience(aud) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRevoked rejects revoked tokens. Denylist errors fail closed.
+func (s *Service) checkRevoked(
+	ctx context.Context,
+	claims *token.Claims,
+) error {
+
+	if s.opts.Denylist == nil {
+		return nil
+	}
+
+	revoked, err := s.opts.Denylist.IsRevoked(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		s.opts.Metrics.TokenRevoked()
+		return errors.New("iam: access token revoked")
+	}
+	return nil
+}
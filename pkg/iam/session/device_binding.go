@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"maps"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/iam/token/pop"
+)
+
+// Device binding (proof of possession for refresh tokens).
+//
+// A session created with CreateRequest.DeviceKey only accepts its
+// refresh token together with a device proof: a JWS of type
+// DeviceProofType, signed by that key, carrying the public JWK in
+// its header and these claims:
+//
+//	iat      issued at (must be recent)
+//	rt_hash  base64url(SHA-256(refresh token))
+//
+// Only the key thumbprint is stored, so a stolen refresh token
+// (or store dump) is useless without the device's private key.
+const (
+	AttrDeviceKeyThumbprint = "device_jkt"
+	DeviceProofType         = "device-proof+jwt"
+
+	deviceProofMaxAge = time.Minute
+)
+
+// ErrInvalidDeviceKey is returned by Create for unusable device keys.
+var ErrInvalidDeviceKey = errors.New("invalid device key")
+
+type deviceProofCtxKey struct{}
+
+// WithDeviceProof attaches a device proof (compact JWS) to ctx.
+//
+// Manager methods taking a refresh token read it from there.
+func WithDeviceProof(ctx context.Context, proof string) context.Context {
+	return context.WithValue(ctx, deviceProofCtxKey{}, proof)
+}
+
+func deviceProofFromContext(ctx context.Context) string {
+	proof, _ := ctx.Value(deviceProofCtxKey{}).(string)
+	return proof
+}
+
+// bindDevice returns attrs carrying the thumbprint of deviceKey (a public JWK).
+func bindDevice(attrs map[string]string, deviceKey string) (map[string]string, error) {
+	if deviceKey == "" {
+		return attrs, nil
+	}
+
+	var jwk keys.JWK
+	if err := json.Unmarshal([]byte(deviceKey), &jwk); err != nil {
+		return nil, ErrInvalidDeviceKey
+	}
+	if _, err := jwk.PublicKey(); err != nil {
+		return nil, ErrInvalidDeviceKey
+	}
+
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, ErrInvalidDeviceKey
+	}
+
+	bound := maps.Clone(attrs)
+	if bound == nil {
+		bound = make(map[string]string, 1)
+	}
+	bound[AttrDeviceKeyThumbprint] = thumbprint
+	return bound, nil
+}
+
+// checkDeviceProof enforces the device binding of sess, if any.
+func checkDeviceProof(
+	ctx context.Context,
+	sess *Session,
+	refreshToken string,
+) error {
+
+	jkt := sess.Attrs[AttrDeviceKeyThumbprint]
+	if jkt == "" {
+		return nil // not bound
+	}
+
+	proof, err := pop.Verify(deviceProofFromContext(ctx), pop.VerifyOptions{
+		Type:   DeviceProofType,
+		MaxAge: deviceProofMaxAge,
+	})
+	if err != nil {
+		return ErrInvalidSession
+	}
+
+	if subtle.ConstantTimeCompare([]byte(proof.Thumbprint), []byte(jkt)) != 1 {
+		return ErrInvalidSession
+	}
+
+	// Bind the proof to this refresh token: proofs for an earlier
+	// token of the family cannot be replayed.
+	if proof.StringClaim("rt_hash") != pop.Hash(refreshToken) {
+		return ErrInvalidSession
+	}
+
+	return nil
+}
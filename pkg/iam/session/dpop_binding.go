@@ -0,0 +1,59 @@
+package session
+
+import (
+	"context"
+	"crypto/subtle"
+	"maps"
+)
+
+// DPoP binding of refresh tokens (RFC 9449 §5).
+//
+// A session created with CreateRequest.DPoPThumbprint (a login made
+// with a DPoP proof) only rotates for refreshes whose DPoP proof uses
+// the same key, see WithDPoPThumbprint. Otherwise a stolen refresh
+// token could be refreshed without a proof into a bearer access token.
+//
+// Proofs are verified by the transport; the manager only compares the
+// key thumbprints.
+const AttrDPoPThumbprint = "dpop_jkt"
+
+type dpopThumbprintCtxKey struct{}
+
+// WithDPoPThumbprint attaches the key thumbprint of the verified DPoP
+// proof sent with a refresh ("" when none was sent) to ctx.
+func WithDPoPThumbprint(ctx context.Context, jkt string) context.Context {
+	return context.WithValue(ctx, dpopThumbprintCtxKey{}, jkt)
+}
+
+func dpopThumbprintFromContext(ctx context.Context) string {
+	jkt, _ := ctx.Value(dpopThumbprintCtxKey{}).(string)
+	return jkt
+}
+
+// bindDPoP returns attrs carrying the DPoP key thumbprint, if any.
+func bindDPoP(attrs map[string]string, jkt string) map[string]string {
+	if jkt == "" {
+		return attrs
+	}
+
+	bound := maps.Clone(attrs)
+	if bound == nil {
+		bound = make(map[string]string, 1)
+	}
+	bound[AttrDPoPThumbprint] = jkt
+	return bound
+}
+
+// checkDPoPBinding enforces the DPoP binding of sess, if any.
+func checkDPoPBinding(ctx context.Context, sess *Session) error {
+	jkt := sess.Attrs[AttrDPoPThumbprint]
+	if jkt == "" {
+		return nil // not bound
+	}
+
+	got := dpopThumbprintFromContext(ctx)
+	if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(jkt)) != 1 {
+		return ErrInvalidSession
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/log"
+	"github.com/kararnab/authdemo/pkg/metrics"
+)
+
+// Sweeper is implemented by stores that keep expired sessions
+// until they are explicitly removed (memory, SQL).
+//
+// Stores with native expiry (Redis) do not need a janitor.
+type Sweeper interface {
+
+	// DeleteExpired removes sessions that expired at or before now
+	// and returns how many were removed.
+	DeleteExpired(
+		ctx context.Context,
+		now time.Time,
+	) (int, error)
+
+	// CountActive returns the number of unexpired sessions
+	// (rotated ones included).
+	CountActive(
+		ctx context.Context,
+		now time.Time,
+	) (int, error)
+}
+
+// JanitorOptions configures the background sweeper.
+type JanitorOptions struct {
+	Interval time.Duration      // default 1m
+	Metrics  metrics.IAMMetrics // optional
+}
+
+// Janitor periodically removes expired sessions from a Sweeper.
+//
+// Lifecycle:
+//   - Start launches the background loop (once)
+//   - Close stops it and waits for an in-flight sweep
+type Janitor struct {
+	sweeper Sweeper
+	opts    JanitorOptions
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewJanitor creates a janitor for the given store.
+func NewJanitor(sweeper Sweeper, opts JanitorOptions) *Janitor {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+
+	return &Janitor{
+		sweeper: sweeper,
+		opts:    opts,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start runs the sweep loop until ctx is cancelled or Close is called.
+func (j *Janitor) Start(ctx context.Context) {
+	j.startOnce.Do(func() {
+		go j.run(ctx)
+	})
+}
+
+// Close stops the loop and waits for it to exit.
+//
+// Returns ctx.Err() if ctx ends first (an in-flight sweep keeps
+// running until its own context is cancelled).
+func (j *Janitor) Close(ctx context.Context) error {
+	j.stopOnce.Do(func() { close(j.stop) })
+
+	// Never started: nothing to wait for.
+	started := true
+	j.startOnce.Do(func() {
+		started = false
+		close(j.done)
+	})
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sweep runs one pass: delete expired sessions, then report counts.
+func (j *Janitor) Sweep(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	n, err := j.sweeper.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	active, err := j.sweeper.CountActive(ctx, now)
+	if err != nil {
+		return n, err
+	}
+
+	if j.opts.Metrics != nil {
+		j.opts.Metrics.SessionsSwept(n)
+		j.opts.Metrics.SessionsActive(active)
+	}
+
+	return n, nil
+}
+
+func (j *Janitor) run(ctx context.Context) {
+	defer close(j.done)
+
+	// Stop in-flight sweeps on Close as well.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-j.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(j.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.Sweep(ctx); err != nil && ctx.Err() == nil {
+				log.Warn(
+					"session sweep failed",
+					log.F("error", err, log.RedactNone),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrSessionLimitReached is returned by Create when a session limit
+// is reached and the policy rejects new logins.
+var ErrSessionLimitReached = errors.New("session limit reached")
+
+// Session attribute keys used to scope limits
+// (same keys as iam.ClientInfo.Attrs).
+const (
+	AttrClientID = "client_id"
+	AttrDeviceID = "device_id"
+)
+
+// Eviction selects what happens when a login would exceed a limit.
+type Eviction int
+
+const (
+	// EvictLeastRecentlyUsed ends the sessions with the oldest LastUsed.
+	EvictLeastRecentlyUsed Eviction = iota
+
+	// EvictOldest ends the sessions with the oldest login (AuthTime).
+	EvictOldest
+
+	// RejectNew refuses the new login with ErrSessionLimitReached.
+	RejectNew
+)
+
+// LimitPolicy caps concurrent sessions (logins) of a subject.
+//
+// Zero values mean "unlimited". Per-client and per-device limits
+// apply to sessions sharing the new login's client_id / device_id
+// attribute. Logins without the attribute are exempt from that cap
+// and count only toward MaxPerSubject.
+//
+// Limits are best-effort: concurrent logins of the same subject
+// may briefly exceed them.
+type LimitPolicy struct {
+	MaxPerSubject int
+	MaxPerClient  int
+	MaxPerDevice  int
+
+	Eviction Eviction
+}
+
+// Eviction reasons reported in EvictedSession.Reason.
+const (
+	EvictReasonSubjectLimit = "subject_limit"
+	EvictReasonClientLimit  = "client_limit"
+	EvictReasonDeviceLimit  = "device_limit"
+)
+
+// EvictedSession is a session ended to make room for a new login.
+type EvictedSession struct {
+	Session *Session
+	Reason  string // EvictReason*
+}
+
+func (p LimitPolicy) enabled() bool {
+	return p.MaxPerSubject > 0 || p.MaxPerClient > 0 || p.MaxPerDevice > 0
+}
+
+// enforceLimits makes room for one more session of req.SubjectID.
+//
+// Narrow scopes go first (device, client, subject) so evictions
+// for a device limit also count towards the subject limit.
+func (m *manager) enforceLimits(
+	ctx context.Context,
+	req CreateRequest,
+	now time.Time,
+) ([]EvictedSession, error) {
+
+	policy := m.opts.Limits
+	if !policy.enabled() {
+		return nil, nil
+	}
+
+	all, err := m.store.ListBySubject(ctx, req.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only live logins count: rotated sessions are reuse-detection
+	// leftovers and idle ones are already dead.
+	active := slices.DeleteFunc(all, func(sess *Session) bool {
+		return sess.Rotated() || m.idle(sess, now)
+	})
+
+	scopes := []struct {
+		max    int
+		reason string
+		match  func(*Session) bool
+	}{
+		{policy.MaxPerDevice, EvictReasonDeviceLimit, sameAttr(AttrDeviceID, req.Attrs)},
+		{policy.MaxPerClient, EvictReasonClientLimit, sameAttr(AttrClientID, req.Attrs)},
+		{policy.MaxPerSubject, EvictReasonSubjectLimit, func(*Session) bool { return true }},
+	}
+
+	var evicted []EvictedSession
+	for _, scope := range scopes {
+		if scope.max <= 0 {
+			continue
+		}
+
+		var group []*Session
+		for _, sess := range active {
+			if scope.match(sess) {
+				group = append(group, sess)
+			}
+		}
+
+		excess := len(group) - scope.max + 1
+		if excess <= 0 {
+			continue
+		}
+		if policy.Eviction == RejectNew {
+			return evicted, ErrSessionLimitReached
+		}
+
+		sortForEviction(group, policy.Eviction)
+
+		for _, victim := range group[:excess] {
+			if err := m.store.DeleteByFamily(ctx, victim.FamilyID); err != nil {
+				return evicted, err
+			}
+			evicted = append(evicted, EvictedSession{Session: victim, Reason: scope.reason})
+			active = slices.DeleteFunc(active, func(sess *Session) bool {
+				return sess.FamilyID == victim.FamilyID
+			})
+		}
+	}
+
+	return evicted, nil
+}
+
+// sortForEviction orders sessions so the first ones are evicted first.
+func sortForEviction(sessions []*Session, eviction Eviction) {
+	slices.SortFunc(sessions, func(a, b *Session) int {
+		if eviction == EvictOldest {
+			return a.AuthTime.Compare(b.AuthTime)
+		}
+		return cmp.Or(
+			a.LastUsed.Compare(b.LastUsed),
+			a.AuthTime.Compare(b.AuthTime),
+		)
+	})
+}
+
+// sameAttr matches sessions whose attribute key equals the one in attrs.
+//
+// Logins without the attribute (no X-Device-ID / X-Client-ID) are not
+// limited by that scope: they match nothing.
+func sameAttr(key string, attrs map[string]string) func(*Session) bool {
+	want := attrs[key]
+	return func(sess *Session) bool {
+		return want != "" && sess.Attrs[key] == want
+	}
+}
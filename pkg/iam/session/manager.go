@@ -10,12 +10,72 @@ import (
 //
 // Sessions are stateful by design.
 type Session struct {
-	ID        string // opaque session identifier (refresh token)
-	SubjectID string // internal subject ID
-	CreatedAt time.Time
+	ID        string    // lookup ID (first half of the refresh token, not secret)
+	SubjectID string    // internal subject ID
+	AuthTime  time.Time // login time, anchors the absolute lifetime (kept across rotation)
+	CreatedAt time.Time // issue time of this refresh token
 	ExpiresAt time.Time
 	LastUsed  time.Time
 	Attrs     map[string]string // device, client_id, ip, etc
+
+	// Refresh token secret.
+	//
+	// Only the SHA-256 digest is persisted. Token is the full
+	// refresh token ("<ID>.<secret>") and is only set on the
+	// session returned by Create / Rotate; stores never see it.
+	SecretHash []byte
+	Token      string
+
+	// Refresh token rotation.
+	//
+	// Every refresh replaces the session with a new one in the same
+	// family. Rotated sessions are kept (until expiry) only to detect
+	// reuse of an already-exchanged refresh token.
+	FamilyID  string    // shared by all sessions descending from one login
+	RotatedAt time.Time // zero while this refresh token is current
+
+	// Subject snapshot taken at login.
+	//
+	// Used to re-issue access tokens with the full claim set on refresh
+	// (unless the IAM service re-resolves the subject).
+	Roles        []string
+	SubjectAttrs map[string]string
+}
+
+// AttrProvider is the Session.Attrs key of the identity provider
+// the session was created by (set by the IAM service at login).
+const AttrProvider = "provider"
+
+// CreateRequest describes a new session.
+type CreateRequest struct {
+	SubjectID    string
+	Roles        []string          // subject roles at login (snapshot)
+	SubjectAttrs map[string]string // subject attributes at login (snapshot)
+	Attrs        map[string]string // session metadata: device, client_id, ip, etc
+
+	// DeviceKey optionally binds the session to a client key
+	// (public JWK, JSON). Refreshes then need a device proof,
+	// see WithDeviceProof.
+	DeviceKey string
+
+	// DPoPThumbprint optionally binds the session to the DPoP key
+	// the login proved (RFC 9449 §5). Refreshes then need a proof
+	// by that key, see WithDPoPThumbprint.
+	DPoPThumbprint string
+}
+
+// CreateResult is returned by Manager.Create.
+type CreateResult struct {
+	Session *Session
+
+	// Evicted lists sessions ended to respect session limits.
+	// It may be non-empty even when Create fails.
+	Evicted []EvictedSession
+}
+
+// Rotated reports whether the session's refresh token has already been exchanged.
+func (s *Session) Rotated() bool {
+	return !s.RotatedAt.IsZero()
 }
 
 // Manager defines session lifecycle operations.
@@ -23,59 +83,104 @@ type Session struct {
 // The Manager owns refresh-token semantics and is responsible for:
 //   - creating sessions
 //   - validating sessions
-//   - revoking sessions
-//   - rotating refresh tokens (eventually)
+//   - revoking sessions (one, one login, or all of a subject)
+//   - listing a subject's sessions
+//   - rotating refresh tokens (with reuse detection)
 type Manager interface {
 
 	// Create establishes a new session for a subject.
 	//
 	// Expected behavior:
-	//   - Generate a secure, opaque session ID
-	//   - Persist session state
-	//   - Enforce session limits per subject/client
+	//   - Generate a lookup ID and a secret (refresh token = both)
+	//   - Persist session state with a digest of the secret only
+	//   - Enforce session limits per subject / client / device
+	//     (evict or return ErrSessionLimitReached, see LimitPolicy)
+	//
+	//   - Bind to a device key when requested (thumbprint in Attrs)
 	//
 	// TODO:
-	//   - Device binding
-	//   - Concurrent session limits
 	//   - Risk-based expiry
 	Create(
 		ctx context.Context,
-		subjectID string,
-		attrs map[string]string,
-	) (*Session, error)
+		req CreateRequest,
+	) (*CreateResult, error)
 
 	// Validate checks whether a session is valid and active.
 	//
 	// Expected behavior:
-	//   - Lookup session
+	//   - Lookup session by ID
+	//   - Compare secret digests in constant time
+	//   - Require a valid device proof for device-bound sessions
 	//   - Check expiry
 	//   - Check revocation status
 	//
-	// TODO:
-	//   - Refresh token rotation detection
-	//   - Sliding expiration
+	//   - Reject rotated (already exchanged) refresh tokens
+	//   - Reject idle sessions (LastUsed older than the idle timeout)
 	Validate(
 		ctx context.Context,
-		sessionID string,
+		refreshToken string,
 	) (*Session, error)
 
-	// Touch updates last-used timestamp for a session.
+	// Rotate exchanges a refresh token for a new one (OAuth 2.1 rotation).
 	//
-	// This is optional but useful for:
-	//   - inactivity-based expiry
-	//   - audit trails
+	// Expected behavior:
+	//   - Mark the presented session as rotated
+	//   - Create a successor in the same family
+	//   - On reuse of a rotated token, revoke the whole family
+	//     and return a *ReuseError
+	//
+	// With sliding expiration the successor expires TTL from now,
+	// capped at the absolute max lifetime; otherwise rotation
+	// never extends the session lifetime.
+	Rotate(
+		ctx context.Context,
+		refreshToken string,
+	) (*Session, error)
+
+	// Touch updates last-used timestamp for a session
+	// (and slides its expiry, if enabled).
+	//
+	// Rotate already counts as activity; Touch is for activity
+	// that does not exchange the refresh token.
 	Touch(
 		ctx context.Context,
-		sessionID string,
+		refreshToken string,
 	) error
 
-	// Revoke invalidates a session permanently.
+	// Revoke invalidates a session permanently and returns it.
+	//
+	// The whole rotation family is revoked, so older refresh
+	// tokens of the same login cannot be replayed afterwards.
 	//
 	// TODO:
-	//   - Revoke all sessions for a subject
 	//   - Revoke by device / client
 	Revoke(
 		ctx context.Context,
-		sessionID string,
+		refreshToken string,
+	) (*Session, error)
+
+	// ListSessions returns the active sessions of a subject,
+	// one per login (rotation family), most recently used first.
+	ListSessions(
+		ctx context.Context,
+		subjectID string,
+	) ([]*Session, error)
+
+	// RevokeSession ends one login of a subject by its family ID
+	// (the stable ID shown by ListSessions).
+	//
+	// Expected behavior:
+	//   - Return ErrInvalidSession if the family does not belong to the subject
+	RevokeSession(
+		ctx context.Context,
+		subjectID string,
+		familyID string,
 	) error
+
+	// RevokeAll ends every session of a subject ("logout everywhere")
+	// and returns the family IDs of the sessions it ended.
+	RevokeAll(
+		ctx context.Context,
+		subjectID string,
+	) ([]string, error)
 }
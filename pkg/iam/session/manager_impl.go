@@ -2,110 +2,389 @@ package session
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
+	"maps"
+	"slices"
 	"time"
 )
 
+var (
+	// ErrInvalidSession is returned for unknown, expired or rotated sessions.
+	ErrInvalidSession = errors.New("invalid or expired session")
+
+	// ErrRefreshTokenReused is matched (errors.Is) by *ReuseError.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+	// ErrAlreadyRotated is returned by Store.MarkRotated for a session
+	// whose refresh token was already exchanged.
+	ErrAlreadyRotated = errors.New("session already rotated")
+)
+
+// ReuseError reports that an already-rotated refresh token was presented.
+//
+// The whole family has been revoked by the time this error is returned.
+type ReuseError struct {
+	SubjectID string
+	FamilyID  string
+}
+
+func (e *ReuseError) Error() string { return ErrRefreshTokenReused.Error() }
+func (e *ReuseError) Unwrap() error { return ErrRefreshTokenReused }
+
+// ManagerOptions configures session lifetimes.
+type ManagerOptions struct {
+	// TTL is the lifetime of a refresh token from its issue.
+	//
+	// Without MaxLifetime it is also the absolute session
+	// lifetime (rotation never extends it).
+	TTL time.Duration
+
+	// MaxLifetime enables sliding expiration: every refresh extends
+	// the session by TTL, but never beyond login + MaxLifetime.
+	// Zero disables sliding.
+	MaxLifetime time.Duration
+
+	// IdleTimeout ends sessions unused (LastUsed) for longer than this,
+	// even if ExpiresAt is still ahead. Zero disables it.
+	IdleTimeout time.Duration
+
+	// Limits caps concurrent sessions per subject / client / device.
+	Limits LimitPolicy
+}
+
 // manager is the default implementation of Manager.
 type manager struct {
 	store Store
-	ttl   time.Duration
+	opts  ManagerOptions
 }
 
 // NewManager creates a session manager using the given store.
 //
-// ttl defines refresh token lifetime.
-//
 // TODO:
-//   - Per-client TTL
-//   - Sliding expiration
-//   - Rotation / reuse detection
+//   - Per-client lifetimes
 func NewManager(
 	store Store,
-	ttl time.Duration,
+	opts ManagerOptions,
 ) Manager {
 	return &manager{
 		store: store,
-		ttl:   ttl,
+		opts:  opts,
 	}
 }
 
 // Create establishes a new session (refresh token).
+//
+// The returned session carries the refresh token in Token;
+// only its digest is persisted.
 func (m *manager) Create(
 	ctx context.Context,
-	subjectID string,
-	attrs map[string]string,
-) (*Session, error) {
+	req CreateRequest,
+) (*CreateResult, error) {
 
-	id, err := generateSessionID()
+	attrs, err := bindDevice(req.Attrs, req.DeviceKey)
 	if err != nil {
 		return nil, err
 	}
+	attrs = bindDPoP(attrs, req.DPoPThumbprint)
 
 	now := time.Now()
 
+	evicted, err := m.enforceLimits(ctx, req, now)
+	if err != nil {
+		return &CreateResult{Evicted: evicted}, err
+	}
+
+	id, secret, token, err := newRefreshToken()
+	if err != nil {
+		return &CreateResult{Evicted: evicted}, err
+	}
+
 	sess := &Session{
-		ID:        id,
-		SubjectID: subjectID,
-		CreatedAt: now,
-		LastUsed:  now,
-		ExpiresAt: now.Add(m.ttl),
-		Attrs:     attrs,
+		ID:           id,
+		SecretHash:   hashSecret(secret),
+		SubjectID:    req.SubjectID,
+		AuthTime:     now,
+		CreatedAt:    now,
+		LastUsed:     now,
+		ExpiresAt:    m.expiry(now, now),
+		Attrs:        attrs,
+		FamilyID:     id, // first session of a family names it
+		Roles:        req.Roles,
+		SubjectAttrs: req.SubjectAttrs,
 	}
 
 	if err := m.store.Save(ctx, sess); err != nil {
-		return nil, err
+		return &CreateResult{Evicted: evicted}, err
 	}
 
-	return sess, nil
+	return &CreateResult{
+		Session: withToken(sess, token),
+		Evicted: evicted,
+	}, nil
 }
 
 // Validate checks whether a session exists and is active.
 func (m *manager) Validate(
 	ctx context.Context,
-	sessionID string,
+	refreshToken string,
 ) (*Session, error) {
 
-	sess, err := m.store.Get(ctx, sessionID)
+	sess, err := m.lookup(ctx, refreshToken)
 	if err != nil {
-		return nil, errors.New("invalid or expired session")
+		return nil, err
+	}
+
+	if sess.Rotated() || m.idle(sess, time.Now()) {
+		return nil, ErrInvalidSession
 	}
 
 	return sess, nil
 }
 
+// Rotate exchanges a refresh token for a new one in the same family.
+func (m *manager) Rotate(
+	ctx context.Context,
+	refreshToken string,
+) (*Session, error) {
+
+	// The secret (and device / DPoP proof) is checked before reuse
+	// detection: knowing a lookup ID alone must not be enough to
+	// revoke a family.
+	sess, err := m.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDPoPBinding(ctx, sess); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	// Idle sessions die even if their refresh token is still unexpired.
+	// Checked before reuse detection: a stale copy of an idle session
+	// is simply rejected.
+	if !sess.Rotated() && m.idle(sess, now) {
+		return nil, ErrInvalidSession
+	}
+
+	// Claim the token first: exactly one caller may rotate it.
+	err = m.store.MarkRotated(ctx, sess.ID, now)
+	if errors.Is(err, ErrAlreadyRotated) {
+		// Reuse of an exchanged token: either the client or an attacker
+		// holds a stale copy. We can't tell which, so end the family.
+		_ = m.store.DeleteByFamily(ctx, sess.FamilyID)
+		return nil, &ReuseError{
+			SubjectID: sess.SubjectID,
+			FamilyID:  sess.FamilyID,
+		}
+	}
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	id, secret, token, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	next := &Session{
+		ID:         id,
+		SecretHash: hashSecret(secret),
+		SubjectID:  sess.SubjectID,
+		AuthTime:   sess.AuthTime,
+		CreatedAt:  now,
+		LastUsed:   now, // a refresh is activity
+		ExpiresAt:  m.slide(sess, now),
+		Attrs:      maps.Clone(sess.Attrs),
+		FamilyID:   sess.FamilyID,
+
+		Roles:        slices.Clone(sess.Roles),
+		SubjectAttrs: maps.Clone(sess.SubjectAttrs),
+	}
+
+	if err := m.store.Save(ctx, next); err != nil {
+		return nil, err
+	}
+
+	return withToken(next, token), nil
+}
+
 // Touch updates last-used timestamp.
 func (m *manager) Touch(
 	ctx context.Context,
-	sessionID string,
+	refreshToken string,
 ) error {
 
-	sess, err := m.store.Get(ctx, sessionID)
+	sess, err := m.lookup(ctx, refreshToken)
 	if err != nil {
 		return err
 	}
 
-	sess.LastUsed = time.Now()
-	return m.store.Update(ctx, sess)
+	now := time.Now()
+	if sess.Rotated() || m.idle(sess, now) {
+		return ErrInvalidSession
+	}
+
+	updated := *sess
+	updated.LastUsed = now
+	updated.ExpiresAt = m.slide(sess, now)
+	return m.store.Update(ctx, &updated)
 }
 
-// Revoke invalidates a session permanently.
+// Revoke invalidates a session and its rotation family permanently.
 func (m *manager) Revoke(
 	ctx context.Context,
-	sessionID string,
+	refreshToken string,
+) (*Session, error) {
+
+	sess, err := m.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.store.DeleteByFamily(ctx, sess.FamilyID); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// ListSessions returns the current (non-rotated) session of every login.
+func (m *manager) ListSessions(
+	ctx context.Context,
+	subjectID string,
+) ([]*Session, error) {
+
+	all, err := m.store.ListBySubject(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rotated sessions are only kept for reuse detection.
+	active := make([]*Session, 0, len(all))
+	for _, sess := range all {
+		if !sess.Rotated() {
+			active = append(active, sess)
+		}
+	}
+
+	slices.SortFunc(active, func(a, b *Session) int {
+		return b.LastUsed.Compare(a.LastUsed)
+	})
+
+	return active, nil
+}
+
+// RevokeSession revokes one login (rotation family) of a subject.
+func (m *manager) RevokeSession(
+	ctx context.Context,
+	subjectID string,
+	familyID string,
 ) error {
-	return m.store.Delete(ctx, sessionID)
+
+	all, err := m.store.ListBySubject(ctx, subjectID)
+	if err != nil {
+		return err
+	}
+
+	// Ownership check: subjects may only end their own logins.
+	owned := slices.ContainsFunc(all, func(sess *Session) bool {
+		return sess.FamilyID == familyID
+	})
+	if !owned {
+		return ErrInvalidSession
+	}
+
+	return m.store.DeleteByFamily(ctx, familyID)
 }
 
-// generateSessionID creates a cryptographically secure opaque token.
-func generateSessionID() (string, error) {
+// RevokeAll revokes every session of a subject.
+func (m *manager) RevokeAll(
+	ctx context.Context,
+	subjectID string,
+) ([]string, error) {
 
-	b := make([]byte, 32) // 256-bit
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+	all, err := m.store.ListBySubject(ctx, subjectID)
+	if err != nil {
+		return nil, err
 	}
 
-	return base64.RawURLEncoding.EncodeToString(b), nil
+	if err := m.store.DeleteBySubject(ctx, subjectID); err != nil {
+		return nil, err
+	}
+
+	var families []string
+	for _, sess := range all {
+		if !slices.Contains(families, sess.FamilyID) {
+			families = append(families, sess.FamilyID)
+		}
+	}
+	return families, nil
+}
+
+// lookup resolves a refresh token to its stored session.
+//
+// Malformed tokens, unknown IDs, wrong secrets and missing device
+// proofs are indistinguishable to the caller (ErrInvalidSession).
+func (m *manager) lookup(
+	ctx context.Context,
+	refreshToken string,
+) (*Session, error) {
+
+	id, secret, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, ErrInvalidSession
+	}
+
+	sess, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	if !secretMatches(sess, secret) {
+		return nil, ErrInvalidSession
+	}
+
+	if err := checkDeviceProof(ctx, sess, refreshToken); err != nil {
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+// expiry returns the expiry of a refresh token issued at now
+// for a login that happened at authTime.
+func (m *manager) expiry(authTime, now time.Time) time.Time {
+	exp := now.Add(m.opts.TTL)
+
+	if m.opts.MaxLifetime > 0 {
+		if limit := authTime.Add(m.opts.MaxLifetime); exp.After(limit) {
+			exp = limit
+		}
+	}
+
+	return exp
+}
+
+// slide returns the new expiry of sess after activity at now.
+//
+// Without sliding expiration the original expiry is kept.
+func (m *manager) slide(sess *Session, now time.Time) time.Time {
+	if m.opts.MaxLifetime <= 0 {
+		return sess.ExpiresAt
+	}
+	return m.expiry(sess.AuthTime, now)
+}
+
+// idle reports whether sess has been unused for longer than IdleTimeout.
+func (m *manager) idle(sess *Session, now time.Time) bool {
+	return m.opts.IdleTimeout > 0 &&
+		now.Sub(sess.LastUsed) > m.opts.IdleTimeout
+}
+
+// withToken returns a copy of sess carrying the client-facing refresh token
+// (stores may keep the original pointer; the token must never be persisted).
+func withToken(sess *Session, token string) *Session {
+	out := *sess
+	out.Token = token
+	return &out
 }
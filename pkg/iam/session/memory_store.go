@@ -7,13 +7,14 @@ import (
 	"time"
 )
 
-// memoryStore is an in-memory, Redis-like implementation of Store.
+// memoryStore is an in-memory, Redis-like implementation of Store
+// (see redisStore for the real thing).
 //
 // Semantics:
 //   - sessionID is the key
 //   - expiry is enforced by the store
 //   - expired sessions behave as "not found"
-//   - expired sessions are deleted on access
+//   - expired sessions are deleted by DeleteExpired (see Janitor)
 type memoryStore struct {
 	mu       sync.RWMutex
 	sessions map[string]*Session
@@ -21,9 +22,8 @@ type memoryStore struct {
 
 // NewMemoryStore creates an in-memory session store.
 //
-// TODO:
-//   - Background cleanup goroutine (optional)
-//   - Metrics hooks
+// Expired sessions are only reclaimed by DeleteExpired:
+// run a Janitor alongside it.
 func NewMemoryStore() Store {
 	return &memoryStore{
 		sessions: make(map[string]*Session),
@@ -59,10 +59,10 @@ func (s *memoryStore) Get(
 		return nil, errors.New("session not found")
 	}
 
-	// Enforce TTL (Redis-like behavior)
-	if time.Now().After(sess.ExpiresAt) {
-		delete(s.sessions, sessionID)
-		return nil, errors.New("session expired")
+	// Enforce TTL (Redis-like behavior). Reads never mutate the map:
+	// deleting here would race with other readers under RLock.
+	if !time.Now().Before(sess.ExpiresAt) {
+		return nil, errors.New("session not found")
 	}
 
 	return sess, nil
@@ -96,6 +96,66 @@ func (s *memoryStore) Delete(
 	return nil
 }
 
+func (s *memoryStore) MarkRotated(
+	ctx context.Context,
+	sessionID string,
+	at time.Time,
+) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[sessionID]
+	if !ok || !time.Now().Before(sess.ExpiresAt) {
+		return errors.New("session not found")
+	}
+	if sess.Rotated() {
+		return ErrAlreadyRotated
+	}
+
+	rotated := *sess
+	rotated.RotatedAt = at
+	s.sessions[sessionID] = &rotated
+	return nil
+}
+
+func (s *memoryStore) DeleteByFamily(
+	ctx context.Context,
+	familyID string,
+) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, sess := range s.sessions {
+		if sess.FamilyID == familyID {
+			delete(s.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) ListBySubject(
+	ctx context.Context,
+	subjectID string,
+) ([]*Session, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+
+	var out []*Session
+	for _, sess := range s.sessions {
+		if sess.SubjectID == subjectID && now.Before(sess.ExpiresAt) {
+			out = append(out, sess)
+		}
+	}
+
+	return out, nil
+}
+
 func (s *memoryStore) DeleteBySubject(
 	ctx context.Context,
 	subjectID string,
@@ -112,3 +172,40 @@ func (s *memoryStore) DeleteBySubject(
 
 	return nil
 }
+
+func (s *memoryStore) DeleteExpired(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, sess := range s.sessions {
+		if !now.Before(sess.ExpiresAt) {
+			delete(s.sessions, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (s *memoryStore) CountActive(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, sess := range s.sessions {
+		if now.Before(sess.ExpiresAt) {
+			n++
+		}
+	}
+
+	return n, nil
+}
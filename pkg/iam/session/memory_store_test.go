@@ -0,0 +1,14 @@
+package session_test
+
+import (
+	"testing"
+
+	"github.com/kararnab/authdemo/pkg/iam/session"
+	"github.com/kararnab/authdemo/pkg/iam/session/sessiontest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	sessiontest.RunStoreTests(t, func(t *testing.T) session.Store {
+		return session.NewMemoryStore()
+	})
+}
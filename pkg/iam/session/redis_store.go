@@ -0,0 +1,448 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// RedisOptions configures the Redis session store.
+type RedisOptions struct {
+	Addr     string // host:port
+	Password string // optional (AUTH)
+	DB       int    // optional (SELECT)
+
+	// KeyPrefix namespaces all keys (default "authdemo:").
+	KeyPrefix string
+
+	DialTimeout time.Duration // default 5s
+	PoolSize    int           // idle connections kept (default 10)
+}
+
+// redisStore is a Redis implementation of Store speaking RESP directly.
+//
+// Key layout:
+//   - {prefix}session:{id}          JSON session, native TTL = ExpiresAt
+//   - {prefix}session:{id}:rotated  rotation marker (SET NX = compare-and-swap)
+//   - {prefix}subject:{subjectID}   set of session IDs (DeleteBySubject, no SCAN)
+//   - {prefix}family:{familyID}     set of session IDs (reuse detection)
+//
+// Expiry is enforced by Redis itself; index sets may briefly reference
+// expired sessions and are cleaned up lazily.
+type redisStore struct {
+	client *respClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed session store.
+//
+// Connections are opened lazily; the first command fails
+// if the server is unreachable.
+func NewRedisStore(opts RedisOptions) Store {
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = "authdemo:"
+	}
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	if opts.PoolSize == 0 {
+		opts.PoolSize = 10
+	}
+
+	return &redisStore{
+		client: newRESPClient(opts.Addr, opts.Password, opts.DB, opts.PoolSize, opts.DialTimeout),
+		prefix: opts.KeyPrefix,
+	}
+}
+
+// redisSession is the stored JSON document (rotation lives in its own key).
+type redisSession struct {
+	ID           string            `json:"id"`
+	SubjectID    string            `json:"sub"`
+	FamilyID     string            `json:"fam"`
+	AuthTime     int64             `json:"auth_time"`
+	CreatedAt    int64             `json:"created_at"`
+	ExpiresAt    int64             `json:"expires_at"`
+	LastUsed     int64             `json:"last_used"`
+	Attrs        map[string]string `json:"attrs,omitempty"`
+	Roles        []string          `json:"roles,omitempty"`
+	SubjectAttrs map[string]string `json:"subject_attrs,omitempty"`
+	SecretHash   []byte            `json:"secret_hash,omitempty"` // base64 in JSON
+}
+
+func (s *redisStore) Save(
+	ctx context.Context,
+	session *Session,
+) error {
+
+	ttl, doc, err := s.encode(session)
+	if err != nil {
+		return err
+	}
+
+	reply, err := s.client.do(ctx, "SET", s.sessionKey(session.ID), doc, "PX", ttl, "NX")
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return errors.New("session already exists")
+	}
+
+	return s.index(ctx, session)
+}
+
+func (s *redisStore) Get(
+	ctx context.Context,
+	sessionID string,
+) (*Session, error) {
+
+	reply, err := s.client.do(ctx, "MGET", s.sessionKey(sessionID), s.rotatedKey(sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := reply.([]any)
+	if len(values) != 2 || values[0] == nil {
+		return nil, errors.New("session not found")
+	}
+
+	sess, err := decodeRedisSession(values[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if raw, ok := values[1].(string); ok {
+		nanos, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		sess.RotatedAt = time.Unix(0, nanos)
+	}
+
+	return sess, nil
+}
+
+func (s *redisStore) Update(
+	ctx context.Context,
+	session *Session,
+) error {
+
+	ttl, doc, err := s.encode(session)
+	if err != nil {
+		return err
+	}
+
+	reply, err := s.client.do(ctx, "SET", s.sessionKey(session.ID), doc, "PX", ttl, "XX")
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return errors.New("session not found")
+	}
+
+	// Keep the rotation marker alive as long as the session (sliding expiry).
+	if _, err := s.client.do(ctx, "PEXPIRE", s.rotatedKey(session.ID), ttl); err != nil {
+		return err
+	}
+
+	return s.index(ctx, session)
+}
+
+func (s *redisStore) Delete(
+	ctx context.Context,
+	sessionID string,
+) error {
+
+	sessions, err := s.load(ctx, []string{sessionID})
+	if err != nil {
+		return err
+	}
+
+	return s.deleteSessions(ctx, []string{sessionID}, sessions)
+}
+
+func (s *redisStore) MarkRotated(
+	ctx context.Context,
+	sessionID string,
+	at time.Time,
+) error {
+
+	pttl, err := s.client.do(ctx, "PTTL", s.sessionKey(sessionID))
+	if err != nil {
+		return err
+	}
+	ms, _ := pttl.(int64)
+	if ms <= 0 {
+		return errors.New("session not found")
+	}
+
+	// SET NX: exactly one concurrent caller wins.
+	reply, err := s.client.do(ctx, "SET", s.rotatedKey(sessionID),
+		strconv.FormatInt(at.UnixNano(), 10), "PX", strconv.FormatInt(ms, 10), "NX")
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return ErrAlreadyRotated
+	}
+	return nil
+}
+
+func (s *redisStore) DeleteByFamily(
+	ctx context.Context,
+	familyID string,
+) error {
+
+	ids, err := s.members(ctx, s.familyKey(familyID))
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.load(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := s.deleteSessions(ctx, ids, sessions); err != nil {
+		return err
+	}
+
+	_, err = s.client.do(ctx, "DEL", s.familyKey(familyID))
+	return err
+}
+
+func (s *redisStore) ListBySubject(
+	ctx context.Context,
+	subjectID string,
+) ([]*Session, error) {
+
+	ids, err := s.members(ctx, s.subjectKey(subjectID))
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// One round trip: session document and rotation marker per ID.
+	args := []string{"MGET"}
+	for _, id := range ids {
+		args = append(args, s.sessionKey(id), s.rotatedKey(id))
+	}
+
+	reply, err := s.client.do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := reply.([]any)
+	out := make([]*Session, 0, len(ids))
+	for i := 0; i+1 < len(values); i += 2 {
+		if values[i] == nil {
+			continue // expired, index cleaned up lazily
+		}
+		sess, err := decodeRedisSession(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if raw, ok := values[i+1].(string); ok {
+			nanos, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			sess.RotatedAt = time.Unix(0, nanos)
+		}
+		out = append(out, sess)
+	}
+	return out, nil
+}
+
+func (s *redisStore) DeleteBySubject(
+	ctx context.Context,
+	subjectID string,
+) error {
+
+	ids, err := s.members(ctx, s.subjectKey(subjectID))
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.load(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := s.deleteSessions(ctx, ids, sessions); err != nil {
+		return err
+	}
+
+	_, err = s.client.do(ctx, "DEL", s.subjectKey(subjectID))
+	return err
+}
+
+// Close releases pooled connections.
+func (s *redisStore) Close() error {
+	s.client.close()
+	return nil
+}
+
+// ================================
+// Helpers
+// ================================
+
+func (s *redisStore) sessionKey(id string) string { return s.prefix + "session:" + id }
+func (s *redisStore) rotatedKey(id string) string { return s.prefix + "session:" + id + ":rotated" }
+func (s *redisStore) subjectKey(id string) string { return s.prefix + "subject:" + id }
+func (s *redisStore) familyKey(id string) string  { return s.prefix + "family:" + id }
+
+// encode returns the TTL (ms) and JSON document for a session.
+func (s *redisStore) encode(sess *Session) (string, string, error) {
+	ttl := time.Until(sess.ExpiresAt).Milliseconds()
+	if ttl <= 0 {
+		return "", "", errors.New("session already expired")
+	}
+
+	doc, err := json.Marshal(redisSession{
+		ID:           sess.ID,
+		SubjectID:    sess.SubjectID,
+		FamilyID:     sess.FamilyID,
+		AuthTime:     sess.AuthTime.UnixNano(),
+		CreatedAt:    sess.CreatedAt.UnixNano(),
+		ExpiresAt:    sess.ExpiresAt.UnixNano(),
+		LastUsed:     sess.LastUsed.UnixNano(),
+		Attrs:        sess.Attrs,
+		Roles:        sess.Roles,
+		SubjectAttrs: sess.SubjectAttrs,
+		SecretHash:   sess.SecretHash,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return strconv.FormatInt(ttl, 10), string(doc), nil
+}
+
+func decodeRedisSession(v any) (*Session, error) {
+	raw, ok := v.(string)
+	if !ok {
+		return nil, errors.New("redis: unexpected session value")
+	}
+
+	var doc redisSession
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		ID:           doc.ID,
+		SubjectID:    doc.SubjectID,
+		FamilyID:     doc.FamilyID,
+		AuthTime:     time.Unix(0, doc.AuthTime),
+		CreatedAt:    time.Unix(0, doc.CreatedAt),
+		ExpiresAt:    time.Unix(0, doc.ExpiresAt),
+		LastUsed:     time.Unix(0, doc.LastUsed),
+		Attrs:        doc.Attrs,
+		Roles:        doc.Roles,
+		SubjectAttrs: doc.SubjectAttrs,
+		SecretHash:   doc.SecretHash,
+	}, nil
+}
+
+// index adds the session to its subject and family sets and makes sure
+// the sets live at least as long as the session.
+func (s *redisStore) index(ctx context.Context, sess *Session) error {
+	for _, key := range []string{s.subjectKey(sess.SubjectID), s.familyKey(sess.FamilyID)} {
+		if _, err := s.client.do(ctx, "SADD", key, sess.ID); err != nil {
+			return err
+		}
+
+		// Only ever extend: other members may outlive this session.
+		reply, err := s.client.do(ctx, "PTTL", key)
+		if err != nil {
+			return err
+		}
+		current, _ := reply.(int64) // -1: no TTL yet
+		want := time.Until(sess.ExpiresAt).Milliseconds()
+		if current == -1 || current < want {
+			if _, err := s.client.do(ctx, "PEXPIRE", key, strconv.FormatInt(want, 10)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *redisStore) members(ctx context.Context, key string) ([]string, error) {
+	reply, err := s.client.do(ctx, "SMEMBERS", key)
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := reply.([]any)
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// load fetches the (still live) sessions among ids.
+func (s *redisStore) load(ctx context.Context, ids []string) ([]*Session, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := []string{"MGET"}
+	for _, id := range ids {
+		args = append(args, s.sessionKey(id))
+	}
+
+	reply, err := s.client.do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := reply.([]any)
+	sessions := make([]*Session, 0, len(values))
+	for _, v := range values {
+		if v == nil {
+			continue // expired
+		}
+		sess, err := decodeRedisSession(v)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, nil
+}
+
+// deleteSessions removes the session keys for ids and unlinks the live
+// ones from their index sets.
+func (s *redisStore) deleteSessions(ctx context.Context, ids []string, live []*Session) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := []string{"DEL"}
+	for _, id := range ids {
+		args = append(args, s.sessionKey(id), s.rotatedKey(id))
+	}
+	if _, err := s.client.do(ctx, args...); err != nil {
+		return err
+	}
+
+	for _, sess := range live {
+		if _, err := s.client.do(ctx, "SREM", s.subjectKey(sess.SubjectID), sess.ID); err != nil {
+			return err
+		}
+		if _, err := s.client.do(ctx, "SREM", s.familyKey(sess.FamilyID), sess.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
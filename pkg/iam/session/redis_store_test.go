@@ -0,0 +1,43 @@
+package session_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/session"
+	"github.com/kararnab/authdemo/pkg/iam/session/sessiontest"
+)
+
+func TestRedisStore(t *testing.T) {
+	var srv *sessiontest.RedisServer
+
+	sessiontest.RunStoreTests(t, func(t *testing.T) session.Store {
+		srv = sessiontest.NewRedisServer(t, "s3cret")
+		return newTestRedisStore(t, srv.Addr(), "s3cret")
+	}, sessiontest.WithElapse(func(d time.Duration) {
+		srv.FastForward(d)
+	}))
+}
+
+func TestRedisStoreWrongPassword(t *testing.T) {
+	srv := sessiontest.NewRedisServer(t, "s3cret")
+	store := newTestRedisStore(t, srv.Addr(), "wrong")
+
+	if _, err := store.Get(context.Background(), "s1"); err == nil {
+		t.Fatal("Get: expected AUTH error")
+	}
+}
+
+func newTestRedisStore(t *testing.T, addr, password string) session.Store {
+	t.Helper()
+
+	store := session.NewRedisStore(session.RedisOptions{
+		Addr:     addr,
+		Password: password,
+		DB:       1,
+	})
+	t.Cleanup(func() { _ = store.(io.Closer).Close() })
+	return store
+}
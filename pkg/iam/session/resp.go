@@ -0,0 +1,219 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// respClient is a minimal RESP2 client (Redis serialization protocol).
+//
+// It only covers what the session store needs: request/response
+// commands over a small connection pool. No pub/sub, no cluster.
+type respClient struct {
+	addr        string
+	password    string
+	db          int
+	dialTimeout time.Duration
+
+	pool chan *respConn
+}
+
+// respError is an error reply sent by the server ("-ERR ...").
+type respError string
+
+func (e respError) Error() string { return "redis: " + string(e) }
+
+type respConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func newRESPClient(addr, password string, db, poolSize int, dialTimeout time.Duration) *respClient {
+	return &respClient{
+		addr:        addr,
+		password:    password,
+		db:          db,
+		dialTimeout: dialTimeout,
+		pool:        make(chan *respConn, poolSize),
+	}
+}
+
+// do sends one command and returns its reply.
+//
+// Reply types: string (simple / bulk), int64, nil (null bulk / array),
+// []any (array). Server errors are returned as respError.
+func (c *respClient) do(ctx context.Context, args ...string) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.roundTrip(ctx, args)
+
+	// Server error replies leave the connection usable; I/O errors do not.
+	var re respError
+	if err == nil || errors.As(err, &re) {
+		c.put(cn)
+	} else {
+		_ = cn.conn.Close()
+	}
+
+	return reply, err
+}
+
+func (c *respClient) get(ctx context.Context) (*respConn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+	}
+
+	d := net.Dialer{Timeout: c.dialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &respConn{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+		w:    bufio.NewWriter(conn),
+	}
+
+	if c.password != "" {
+		if _, err := cn.roundTrip(ctx, []string{"AUTH", c.password}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err := cn.roundTrip(ctx, []string{"SELECT", strconv.Itoa(c.db)}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+func (c *respClient) put(cn *respConn) {
+	select {
+	case c.pool <- cn:
+	default:
+		_ = cn.conn.Close() // pool full
+	}
+}
+
+// close drops all pooled connections.
+func (c *respClient) close() {
+	for {
+		select {
+		case cn := <-c.pool:
+			_ = cn.conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (cn *respConn) roundTrip(ctx context.Context, args []string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	if err := cn.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	// Request: array of bulk strings.
+	cn.w.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		cn.w.WriteString("$" + strconv.Itoa(len(a)) + "\r\n")
+		cn.w.WriteString(a)
+		cn.w.WriteString("\r\n")
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return cn.readReply()
+}
+
+func (cn *respConn) readReply() (any, error) {
+	line, err := cn.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+
+	case '-':
+		return nil, respError(line[1:])
+
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2) // payload + CRLF
+		if _, err := io.ReadFull(cn.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		out := make([]any, n)
+		for i := range out {
+			// Nested error replies (e.g. inside EXEC) are surfaced as values.
+			v, err := cn.readReply()
+			var re respError
+			if err != nil && !errors.As(err, &re) {
+				return nil, err
+			}
+			if err != nil {
+				v = re
+			}
+			out[i] = v
+		}
+		return out, nil
+
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", line[0])
+	}
+}
+
+func (cn *respConn) readLine() (string, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("redis: malformed reply line")
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,433 @@
+package sessiontest
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// RedisServer is an in-process stand-in for Redis speaking RESP2, so the
+// Redis session store can be tested without an external service:
+//
+//	srv := sessiontest.NewRedisServer(t, "")
+//	store := session.NewRedisStore(session.RedisOptions{Addr: srv.Addr()})
+//
+// It implements only the commands the session store uses (strings with
+// PX / NX / XX, sets, key expiry, AUTH, SELECT) on a single keyspace.
+// Keys expire lazily against the server clock, which FastForward moves
+// ahead without sleeping.
+type RedisServer struct {
+	ln       net.Listener
+	password string
+
+	mu      sync.Mutex
+	offset  time.Duration // FastForward total
+	strs    map[string]string
+	sets    map[string]map[string]struct{}
+	expires map[string]time.Time // keys with a TTL
+
+	wg sync.WaitGroup
+}
+
+// NewRedisServer starts a stand-in on a random local port; it is closed
+// when the test ends. A non-empty password requires AUTH.
+func NewRedisServer(t testing.TB, password string) *RedisServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("redis stand-in: listen: %v", err)
+	}
+
+	srv := &RedisServer{
+		ln:       ln,
+		password: password,
+		strs:     make(map[string]string),
+		sets:     make(map[string]map[string]struct{}),
+		expires:  make(map[string]time.Time),
+	}
+
+	srv.wg.Add(1)
+	go srv.serve()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// Addr is the host:port to connect to.
+func (s *RedisServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// FastForward advances the server clock by d: keys whose TTL ends
+// within d expire.
+func (s *RedisServer) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset += d
+}
+
+// Close stops the server and waits for open connections to end.
+func (s *RedisServer) Close() {
+	_ = s.ln.Close()
+	s.wg.Wait()
+}
+
+func (s *RedisServer) serve() {
+	defer s.wg.Done()
+
+	var (
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+	)
+	defer func() {
+		// Unblock handlers waiting for the client's next command.
+		mu.Lock()
+		for c := range conns {
+			_ = c.Close()
+		}
+		mu.Unlock()
+	}()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+		}()
+	}
+}
+
+// handle serves the commands of one connection.
+func (s *RedisServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	authed := s.password == ""
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				writeError(w, "ERR Protocol error: "+err.Error())
+				_ = w.Flush()
+			}
+			return
+		}
+
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "AUTH":
+			if len(args) == 2 && args[1] == s.password {
+				authed = true
+				writeSimple(w, "OK")
+			} else {
+				writeError(w, "WRONGPASS invalid password")
+			}
+
+		case !authed:
+			writeError(w, "NOAUTH Authentication required.")
+
+		default:
+			s.exec(w, name, args[1:])
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// exec runs one command against the keyspace.
+func (s *RedisServer) exec(w *bufio.Writer, name string, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch name {
+	case "PING":
+		writeSimple(w, "PONG")
+
+	case "SELECT":
+		writeSimple(w, "OK") // single keyspace
+
+	case "SET":
+		s.set(w, args)
+
+	case "MGET":
+		writeArrayHeader(w, len(args))
+		for _, key := range args {
+			if v, ok := s.str(key); ok {
+				writeBulk(w, v)
+			} else {
+				writeNull(w)
+			}
+		}
+
+	case "DEL":
+		n := 0
+		for _, key := range args {
+			if s.exists(key) {
+				s.remove(key)
+				n++
+			}
+		}
+		writeInt(w, n)
+
+	case "PEXPIRE":
+		if len(args) != 2 {
+			writeError(w, "ERR wrong number of arguments for 'pexpire' command")
+			return
+		}
+		ms, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			writeError(w, "ERR value is not an integer or out of range")
+			return
+		}
+		if !s.exists(args[0]) {
+			writeInt(w, 0)
+			return
+		}
+		s.expires[args[0]] = s.now().Add(time.Duration(ms) * time.Millisecond)
+		writeInt(w, 1)
+
+	case "PTTL":
+		if len(args) != 1 {
+			writeError(w, "ERR wrong number of arguments for 'pttl' command")
+			return
+		}
+		switch at, ok := s.expires[args[0]]; {
+		case !s.exists(args[0]):
+			writeInt(w, -2)
+		case !ok:
+			writeInt(w, -1)
+		default:
+			writeInt(w, int(at.Sub(s.now()).Milliseconds()))
+		}
+
+	case "SADD", "SREM":
+		if len(args) < 2 {
+			writeError(w, "ERR wrong number of arguments for '"+strings.ToLower(name)+"' command")
+			return
+		}
+		if _, ok := s.str(args[0]); ok {
+			writeError(w, "WRONGTYPE Operation against a key holding the wrong kind of value")
+			return
+		}
+		writeInt(w, s.updateSet(name == "SADD", args[0], args[1:]))
+
+	case "SMEMBERS":
+		if len(args) != 1 {
+			writeError(w, "ERR wrong number of arguments for 'smembers' command")
+			return
+		}
+		s.exists(args[0]) // expire first
+		members := make([]string, 0, len(s.sets[args[0]]))
+		for m := range s.sets[args[0]] {
+			members = append(members, m)
+		}
+		slices.Sort(members)
+		writeArrayHeader(w, len(members))
+		for _, m := range members {
+			writeBulk(w, m)
+		}
+
+	default:
+		writeError(w, "ERR unknown command '"+name+"'")
+	}
+}
+
+// set implements SET key value [PX ms] [NX | XX].
+func (s *RedisServer) set(w *bufio.Writer, args []string) {
+	if len(args) < 2 {
+		writeError(w, "ERR wrong number of arguments for 'set' command")
+		return
+	}
+	key, value := args[0], args[1]
+
+	var (
+		ttl    time.Duration
+		nx, xx bool
+	)
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "PX":
+			if i+1 >= len(args) {
+				writeError(w, "ERR syntax error")
+				return
+			}
+			ms, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || ms <= 0 {
+				writeError(w, "ERR invalid expire time in 'set' command")
+				return
+			}
+			ttl = time.Duration(ms) * time.Millisecond
+			i++
+		default:
+			writeError(w, "ERR syntax error")
+			return
+		}
+	}
+
+	exists := s.exists(key)
+	if (nx && exists) || (xx && !exists) {
+		writeNull(w)
+		return
+	}
+
+	s.remove(key)
+	s.strs[key] = value
+	if ttl > 0 {
+		s.expires[key] = s.now().Add(ttl)
+	}
+	writeSimple(w, "OK")
+}
+
+// updateSet adds or removes members and returns how many changed.
+func (s *RedisServer) updateSet(add bool, key string, members []string) int {
+	s.exists(key) // expire first
+
+	set := s.sets[key]
+	if set == nil {
+		if !add {
+			return 0
+		}
+		set = make(map[string]struct{})
+		s.sets[key] = set
+	}
+
+	n := 0
+	for _, m := range members {
+		_, ok := set[m]
+		switch {
+		case add && !ok:
+			set[m] = struct{}{}
+			n++
+		case !add && ok:
+			delete(set, m)
+			n++
+		}
+	}
+
+	// Redis deletes empty sets (and their TTL).
+	if len(set) == 0 {
+		s.remove(key)
+	}
+	return n
+}
+
+func (s *RedisServer) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// exists reports whether key is live, dropping it first if expired.
+func (s *RedisServer) exists(key string) bool {
+	if at, ok := s.expires[key]; ok && !s.now().Before(at) {
+		s.remove(key)
+	}
+
+	_, str := s.strs[key]
+	_, set := s.sets[key]
+	return str || set
+}
+
+func (s *RedisServer) str(key string) (string, bool) {
+	if !s.exists(key) {
+		return "", false
+	}
+	v, ok := s.strs[key]
+	return v, ok
+}
+
+func (s *RedisServer) remove(key string) {
+	delete(s.strs, key)
+	delete(s.sets, key)
+	delete(s.expires, key)
+}
+
+// ================================
+// RESP encoding
+// ================================
+
+// readCommand reads one request: an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 2 || line[0] != '*' {
+		return nil, errors.New("expected '*'")
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n <= 0 {
+		return nil, errors.New("invalid multibulk length")
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) < 2 || line[0] != '$' {
+			return nil, errors.New("expected '$'")
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, errors.New("invalid bulk length")
+		}
+
+		buf := make([]byte, size+2) // payload + CRLF
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+func writeSimple(w *bufio.Writer, s string) { w.WriteString("+" + s + "\r\n") }
+func writeError(w *bufio.Writer, s string)  { w.WriteString("-" + s + "\r\n") }
+func writeInt(w *bufio.Writer, n int)       { w.WriteString(":" + strconv.Itoa(n) + "\r\n") }
+func writeNull(w *bufio.Writer)             { w.WriteString("$-1\r\n") }
+
+func writeBulk(w *bufio.Writer, s string) {
+	w.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
+
+func writeArrayHeader(w *bufio.Writer, n int) {
+	w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
@@ -0,0 +1,358 @@
+// Package sessiontest provides a conformance suite for session.Store
+// implementations.
+//
+// Every store (memory, SQL, Redis, ...) must pass the same suite so they
+// stay interchangeable:
+//
+//	func TestSQLStore(t *testing.T) {
+//		sessiontest.RunStoreTests(t, func(t *testing.T) session.Store {
+//			return newTestSQLStore(t)
+//		})
+//	}
+package sessiontest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/session"
+)
+
+// NewStoreFunc returns an empty store; it is called once per subtest.
+type NewStoreFunc func(t *testing.T) session.Store
+
+// Option customizes the suite.
+type Option func(*suite)
+
+type suite struct {
+	elapse func(d time.Duration)
+}
+
+// WithElapse replaces real sleeping when the suite needs time to pass.
+//
+// Useful for backends with their own clock (e.g. an in-process Redis
+// stand-in that only expires keys when fast-forwarded). The function
+// applies to the store most recently returned by NewStoreFunc.
+func WithElapse(elapse func(d time.Duration)) Option {
+	return func(s *suite) { s.elapse = elapse }
+}
+
+// RunStoreTests runs the session.Store conformance suite.
+func RunStoreTests(t *testing.T, newStore NewStoreFunc, opts ...Option) {
+	t.Helper()
+
+	cfg := &suite{elapse: time.Sleep}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	t.Run("SaveGet", func(t *testing.T) { testSaveGet(t, newStore(t)) })
+	t.Run("SaveDuplicate", func(t *testing.T) { testSaveDuplicate(t, newStore(t)) })
+	t.Run("GetUnknown", func(t *testing.T) { testGetUnknown(t, newStore(t)) })
+	t.Run("GetExpired", func(t *testing.T) { testGetExpired(t, newStore(t), cfg.elapse) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newStore(t)) })
+	t.Run("UpdateUnknown", func(t *testing.T) { testUpdateUnknown(t, newStore(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStore(t)) })
+	t.Run("MarkRotated", func(t *testing.T) { testMarkRotated(t, newStore(t)) })
+	t.Run("DeleteByFamily", func(t *testing.T) { testDeleteByFamily(t, newStore(t)) })
+	t.Run("ListBySubject", func(t *testing.T) { testListBySubject(t, newStore(t)) })
+	t.Run("DeleteBySubject", func(t *testing.T) { testDeleteBySubject(t, newStore(t)) })
+
+	// Optional capabilities.
+	t.Run("Sweeper", func(t *testing.T) {
+		s, ok := newStore(t).(session.Sweeper)
+		if !ok {
+			t.Skip("store does not implement session.Sweeper")
+		}
+		testSweeper(t, s.(session.Store), s)
+	})
+}
+
+// NewSession returns a valid, unexpired session for use in store tests.
+func NewSession(id, subjectID string) *session.Session {
+	now := time.Now().Truncate(time.Millisecond)
+	secretHash := sha256.Sum256([]byte("secret-" + id))
+	return &session.Session{
+		ID:           id,
+		SecretHash:   secretHash[:],
+		SubjectID:    subjectID,
+		FamilyID:     id,
+		AuthTime:     now.Add(-time.Minute),
+		CreatedAt:    now,
+		LastUsed:     now,
+		ExpiresAt:    now.Add(time.Hour),
+		Attrs:        map[string]string{"ip": "203.0.113.7"},
+		Roles:        []string{"admin"},
+		SubjectAttrs: map[string]string{"email": subjectID + "@example.com"},
+	}
+}
+
+func testSaveGet(t *testing.T, s session.Store) {
+	ctx := context.Background()
+	want := NewSession("s1", "u1")
+
+	mustSave(t, s, want)
+
+	got, err := s.Get(ctx, want.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertEqual(t, got, want)
+}
+
+func testSaveDuplicate(t *testing.T, s session.Store) {
+	mustSave(t, s, NewSession("s1", "u1"))
+
+	if err := s.Save(context.Background(), NewSession("s1", "u2")); err == nil {
+		t.Fatal("Save: expected error for duplicate ID")
+	}
+}
+
+func testGetUnknown(t *testing.T, s session.Store) {
+	if _, err := s.Get(context.Background(), "missing"); err == nil {
+		t.Fatal("Get: expected error for unknown session")
+	}
+}
+
+func testGetExpired(t *testing.T, s session.Store, elapse func(time.Duration)) {
+	sess := NewSession("s1", "u1")
+	sess.ExpiresAt = time.Now().Add(50 * time.Millisecond)
+	mustSave(t, s, sess)
+
+	elapse(100 * time.Millisecond)
+
+	if _, err := s.Get(context.Background(), sess.ID); err == nil {
+		t.Fatal("Get: expired session must behave as not found")
+	}
+}
+
+func testUpdate(t *testing.T, s session.Store) {
+	ctx := context.Background()
+	sess := NewSession("s1", "u1")
+	mustSave(t, s, sess)
+
+	updated := *sess
+	updated.LastUsed = sess.LastUsed.Add(time.Minute)
+	updated.Attrs = map[string]string{"device": "laptop"}
+
+	if err := s.Update(ctx, &updated); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got, err := s.Get(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertEqual(t, got, &updated)
+}
+
+func testUpdateUnknown(t *testing.T, s session.Store) {
+	if err := s.Update(context.Background(), NewSession("missing", "u1")); err == nil {
+		t.Fatal("Update: expected error for unknown session")
+	}
+}
+
+func testDelete(t *testing.T, s session.Store) {
+	ctx := context.Background()
+	mustSave(t, s, NewSession("s1", "u1"))
+
+	if err := s.Delete(ctx, "s1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, "s1"); err == nil {
+		t.Fatal("Get: deleted session still present")
+	}
+
+	// Deleting twice is not an error.
+	if err := s.Delete(ctx, "s1"); err != nil {
+		t.Fatalf("Delete (again): %v", err)
+	}
+}
+
+func testMarkRotated(t *testing.T, s session.Store) {
+	ctx := context.Background()
+	mustSave(t, s, NewSession("s1", "u1"))
+
+	at := time.Now().Truncate(time.Millisecond)
+	if err := s.MarkRotated(ctx, "s1", at); err != nil {
+		t.Fatalf("MarkRotated: %v", err)
+	}
+	if err := s.MarkRotated(ctx, "s1", at); !errors.Is(err, session.ErrAlreadyRotated) {
+		t.Fatalf("MarkRotated (again): got %v, want ErrAlreadyRotated", err)
+	}
+	if err := s.MarkRotated(ctx, "missing", at); err == nil || errors.Is(err, session.ErrAlreadyRotated) {
+		t.Fatalf("MarkRotated (unknown): got %v, want not found", err)
+	}
+
+	got, err := s.Get(ctx, "s1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !got.RotatedAt.Equal(at) {
+		t.Fatalf("RotatedAt = %v, want %v", got.RotatedAt, at)
+	}
+}
+
+func testDeleteByFamily(t *testing.T, s session.Store) {
+	ctx := context.Background()
+
+	a := NewSession("a", "u1")
+	b := NewSession("b", "u1")
+	b.FamilyID = "a"
+	other := NewSession("c", "u1")
+	mustSave(t, s, a)
+	mustSave(t, s, b)
+	mustSave(t, s, other)
+
+	if err := s.DeleteByFamily(ctx, "a"); err != nil {
+		t.Fatalf("DeleteByFamily: %v", err)
+	}
+
+	assertGone(t, s, "a", "b")
+	assertPresent(t, s, "c")
+}
+
+func testListBySubject(t *testing.T, s session.Store) {
+	ctx := context.Background()
+
+	mustSave(t, s, NewSession("a", "u1"))
+	mustSave(t, s, NewSession("b", "u1"))
+	mustSave(t, s, NewSession("c", "u2"))
+	if err := s.MarkRotated(ctx, "b", time.Now()); err != nil {
+		t.Fatalf("MarkRotated: %v", err)
+	}
+
+	got, err := s.ListBySubject(ctx, "u1")
+	if err != nil {
+		t.Fatalf("ListBySubject: %v", err)
+	}
+
+	byID := make(map[string]*session.Session, len(got))
+	for _, sess := range got {
+		byID[sess.ID] = sess
+	}
+	if len(byID) != 2 || byID["a"] == nil || byID["b"] == nil {
+		t.Fatalf("ListBySubject(u1) = %v, want [a b]", got)
+	}
+	if byID["a"].Rotated() || !byID["b"].Rotated() {
+		t.Fatal("ListBySubject: rotation state not preserved")
+	}
+
+	got, err = s.ListBySubject(ctx, "nobody")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("ListBySubject(unknown) = %v, %v; want empty", got, err)
+	}
+}
+
+func testDeleteBySubject(t *testing.T, s session.Store) {
+	ctx := context.Background()
+
+	mustSave(t, s, NewSession("a", "u1"))
+	mustSave(t, s, NewSession("b", "u1"))
+	mustSave(t, s, NewSession("c", "u2"))
+
+	if err := s.DeleteBySubject(ctx, "u1"); err != nil {
+		t.Fatalf("DeleteBySubject: %v", err)
+	}
+
+	assertGone(t, s, "a", "b")
+	assertPresent(t, s, "c")
+}
+
+func testSweeper(t *testing.T, s session.Store, sw session.Sweeper) {
+	ctx := context.Background()
+
+	live := NewSession("live", "u1")
+	expiring := NewSession("expiring", "u1")
+	expiring.ExpiresAt = time.Now().Add(time.Minute)
+	mustSave(t, s, live)
+	mustSave(t, s, expiring)
+
+	// Sweep "in the future" instead of waiting.
+	later := time.Now().Add(2 * time.Minute)
+
+	n, err := sw.DeleteExpired(ctx, later)
+	if err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("DeleteExpired removed %d sessions, want 1", n)
+	}
+
+	active, err := sw.CountActive(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("CountActive: %v", err)
+	}
+	if active != 1 {
+		t.Fatalf("CountActive = %d, want 1", active)
+	}
+
+	assertPresent(t, s, "live")
+}
+
+// ================================
+// Helpers
+// ================================
+
+func mustSave(t *testing.T, s session.Store, sess *session.Session) {
+	t.Helper()
+	if err := s.Save(context.Background(), sess); err != nil {
+		t.Fatalf("Save(%s): %v", sess.ID, err)
+	}
+}
+
+func assertGone(t *testing.T, s session.Store, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if _, err := s.Get(context.Background(), id); err == nil {
+			t.Fatalf("session %s still present", id)
+		}
+	}
+}
+
+func assertPresent(t *testing.T, s session.Store, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if _, err := s.Get(context.Background(), id); err != nil {
+			t.Fatalf("session %s missing: %v", id, err)
+		}
+	}
+}
+
+func assertEqual(t *testing.T, got, want *session.Session) {
+	t.Helper()
+
+	switch {
+	case got.ID != want.ID,
+		got.SubjectID != want.SubjectID,
+		got.FamilyID != want.FamilyID,
+		!got.AuthTime.Equal(want.AuthTime),
+		!got.CreatedAt.Equal(want.CreatedAt),
+		!got.ExpiresAt.Equal(want.ExpiresAt),
+		!got.LastUsed.Equal(want.LastUsed),
+		!got.RotatedAt.Equal(want.RotatedAt),
+		!bytes.Equal(got.SecretHash, want.SecretHash),
+		!equalMaps(got.Attrs, want.Attrs),
+		!slices.Equal(got.Roles, want.Roles),
+		!equalMaps(got.SubjectAttrs, want.SubjectAttrs):
+		t.Fatalf("session mismatch:\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+// equalMaps treats nil and empty maps as equal (stores may normalize either way).
+func equalMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
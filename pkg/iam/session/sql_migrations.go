@@ -0,0 +1,129 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration is one forward-only schema step.
+//
+// Migrations are append-only: never edit a released step,
+// add a new one instead.
+type migration struct {
+	version    int
+	statements []string
+}
+
+// sqlMigrations is the session schema history.
+//
+// Types are portable between SQLite and Postgres
+// (TEXT, BIGINT; timestamps as unix nanoseconds).
+var sqlMigrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS sessions (
+				id            TEXT PRIMARY KEY,
+				subject_id    TEXT NOT NULL,
+				family_id     TEXT NOT NULL,
+				created_at    BIGINT NOT NULL,
+				expires_at    BIGINT NOT NULL,
+				last_used     BIGINT NOT NULL,
+				rotated_at    BIGINT,
+				attrs         TEXT NOT NULL,
+				roles         TEXT NOT NULL,
+				subject_attrs TEXT NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS sessions_subject_expires_idx ON sessions (subject_id, expires_at)`,
+			`CREATE INDEX IF NOT EXISTS sessions_family_idx ON sessions (family_id)`,
+			`CREATE INDEX IF NOT EXISTS sessions_expires_idx ON sessions (expires_at)`,
+		},
+	},
+	{
+		// Refresh tokens are "<id>.<secret>"; only the secret digest is stored.
+		// Pre-existing rows get an empty digest and can no longer be redeemed.
+		version: 2,
+		statements: []string{
+			`ALTER TABLE sessions ADD COLUMN secret_hash TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		// Login time of the rotation family (sliding expiration cap).
+		// 0 = unknown (pre-existing rows), read back as created_at.
+		version: 3,
+		statements: []string{
+			`ALTER TABLE sessions ADD COLUMN auth_time BIGINT NOT NULL DEFAULT 0`,
+		},
+	},
+}
+
+// MigrateSQL brings the session schema up to date.
+//
+// Applied versions are tracked in session_schema_migrations;
+// each step runs in its own transaction. Safe to call on every start.
+func MigrateSQL(
+	ctx context.Context,
+	db *sql.DB,
+	dialect Dialect,
+) error {
+
+	store := &sqlStore{db: db, dialect: dialect}
+
+	if _, err := db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS session_schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			applied_at BIGINT NOT NULL
+		)`,
+	); err != nil {
+		return fmt.Errorf("session: create migrations table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM session_schema_migrations`,
+	).Scan(&current); err != nil {
+		return fmt.Errorf("session: read schema version: %w", err)
+	}
+
+	for _, m := range sqlMigrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := applyMigration(ctx, store, m); err != nil {
+			return fmt.Errorf("session: migration %d: %w", m.version, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(
+	ctx context.Context,
+	s *sqlStore,
+	m migration,
+) error {
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, stmt := range m.statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, s.rebind(
+		`INSERT INTO session_schema_migrations (version, applied_at) VALUES (?, ?)`),
+		m.version, time.Now().UnixNano(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
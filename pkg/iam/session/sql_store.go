@@ -0,0 +1,379 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect selects SQL flavour differences (placeholders, DDL types).
+//
+// The schema sticks to portable types so the same migrations
+// run on SQLite and Postgres.
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// sqlStore is a database/sql implementation of Store.
+//
+// Semantics (same as memoryStore):
+//   - sessionID is the primary key
+//   - expired sessions behave as "not found"
+//   - timestamps are stored as unix nanoseconds
+//   - attrs / roles are stored as JSON text
+//   - the refresh token secret digest is stored hex-encoded
+//
+// Indexes on subject, family and expiry keep "logout everywhere",
+// reuse detection and expiry sweeps off full table scans.
+type sqlStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore creates a session store on top of db.
+//
+// The schema must be up to date: call MigrateSQL first.
+func NewSQLStore(db *sql.DB, dialect Dialect) Store {
+	return &sqlStore{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// sessionColumns is the column list shared by INSERT and SELECT.
+const sessionColumns = `id, subject_id, family_id, created_at, expires_at, last_used,
+	rotated_at, attrs, roles, subject_attrs, secret_hash, auth_time`
+
+func (s *sqlStore) Save(
+	ctx context.Context,
+	session *Session,
+) error {
+
+	row, err := toRow(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, s.rebind(
+		`INSERT INTO sessions (`+sessionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		row.args()...,
+	)
+	if err != nil {
+		// Primary key violation is the only expected failure here.
+		return errors.New("session already exists")
+	}
+	return nil
+}
+
+func (s *sqlStore) Get(
+	ctx context.Context,
+	sessionID string,
+) (*Session, error) {
+
+	r := s.db.QueryRowContext(ctx, s.rebind(
+		`SELECT `+sessionColumns+` FROM sessions
+		WHERE id = ? AND expires_at > ?`),
+		sessionID, time.Now().UnixNano(),
+	)
+
+	sess, err := scanSession(r)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("session not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+func (s *sqlStore) Update(
+	ctx context.Context,
+	session *Session,
+) error {
+
+	row, err := toRow(session)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.ExecContext(ctx, s.rebind(
+		`UPDATE sessions SET
+			subject_id = ?, family_id = ?, created_at = ?, expires_at = ?, last_used = ?,
+			rotated_at = ?, attrs = ?, roles = ?, subject_attrs = ?, secret_hash = ?,
+			auth_time = ?
+		WHERE id = ?`),
+		append(row.args()[1:], row.id)...,
+	)
+	if err != nil {
+		return err
+	}
+	return expectOne(res)
+}
+
+func (s *sqlStore) Delete(
+	ctx context.Context,
+	sessionID string,
+) error {
+
+	_, err := s.db.ExecContext(ctx, s.rebind(
+		`DELETE FROM sessions WHERE id = ?`),
+		sessionID,
+	)
+	return err
+}
+
+func (s *sqlStore) MarkRotated(
+	ctx context.Context,
+	sessionID string,
+	at time.Time,
+) error {
+
+	// Conditional update is the compare-and-swap: one winner only.
+	res, err := s.db.ExecContext(ctx, s.rebind(
+		`UPDATE sessions SET rotated_at = ?
+		WHERE id = ? AND rotated_at IS NULL`),
+		at.UnixNano(), sessionID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 1 {
+		return err
+	}
+
+	// Nothing updated: either unknown or already rotated.
+	var exists int
+	err = s.db.QueryRowContext(ctx, s.rebind(
+		`SELECT 1 FROM sessions WHERE id = ?`),
+		sessionID,
+	).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("session not found")
+	}
+	if err != nil {
+		return err
+	}
+	return ErrAlreadyRotated
+}
+
+func (s *sqlStore) DeleteByFamily(
+	ctx context.Context,
+	familyID string,
+) error {
+
+	_, err := s.db.ExecContext(ctx, s.rebind(
+		`DELETE FROM sessions WHERE family_id = ?`),
+		familyID,
+	)
+	return err
+}
+
+func (s *sqlStore) ListBySubject(
+	ctx context.Context,
+	subjectID string,
+) ([]*Session, error) {
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT `+sessionColumns+` FROM sessions
+		WHERE subject_id = ? AND expires_at > ?`),
+		subjectID, time.Now().UnixNano(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*Session
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sess)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqlStore) DeleteBySubject(
+	ctx context.Context,
+	subjectID string,
+) error {
+
+	_, err := s.db.ExecContext(ctx, s.rebind(
+		`DELETE FROM sessions WHERE subject_id = ?`),
+		subjectID,
+	)
+	return err
+}
+
+func (s *sqlStore) DeleteExpired(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+
+	res, err := s.db.ExecContext(ctx, s.rebind(
+		`DELETE FROM sessions WHERE expires_at <= ?`),
+		now.UnixNano(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (s *sqlStore) CountActive(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+
+	var n int
+	err := s.db.QueryRowContext(ctx, s.rebind(
+		`SELECT COUNT(*) FROM sessions WHERE expires_at > ?`),
+		now.UnixNano(),
+	).Scan(&n)
+	return n, err
+}
+
+// rebind rewrites "?" placeholders for the dialect ("$1", "$2", ... on Postgres).
+func (s *sqlStore) rebind(query string) string {
+	if s.dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// ================================
+// Row mapping
+// ================================
+
+// sessionRow is the storage representation of a Session.
+type sessionRow struct {
+	id           string
+	subjectID    string
+	familyID     string
+	createdAt    int64
+	expiresAt    int64
+	lastUsed     int64
+	rotatedAt    sql.NullInt64
+	attrs        string
+	roles        string
+	subjectAttrs string
+	secretHash   string
+	authTime     int64
+}
+
+func (r sessionRow) args() []any {
+	return []any{
+		r.id, r.subjectID, r.familyID, r.createdAt, r.expiresAt, r.lastUsed,
+		r.rotatedAt, r.attrs, r.roles, r.subjectAttrs, r.secretHash,
+		r.authTime,
+	}
+}
+
+func toRow(sess *Session) (sessionRow, error) {
+	attrs, err := json.Marshal(sess.Attrs)
+	if err != nil {
+		return sessionRow{}, err
+	}
+	roles, err := json.Marshal(sess.Roles)
+	if err != nil {
+		return sessionRow{}, err
+	}
+	subjectAttrs, err := json.Marshal(sess.SubjectAttrs)
+	if err != nil {
+		return sessionRow{}, err
+	}
+
+	row := sessionRow{
+		id:           sess.ID,
+		subjectID:    sess.SubjectID,
+		familyID:     sess.FamilyID,
+		createdAt:    sess.CreatedAt.UnixNano(),
+		expiresAt:    sess.ExpiresAt.UnixNano(),
+		lastUsed:     sess.LastUsed.UnixNano(),
+		attrs:        string(attrs),
+		roles:        string(roles),
+		subjectAttrs: string(subjectAttrs),
+		secretHash:   hex.EncodeToString(sess.SecretHash),
+		authTime:     sess.AuthTime.UnixNano(),
+	}
+	if sess.Rotated() {
+		row.rotatedAt = sql.NullInt64{Int64: sess.RotatedAt.UnixNano(), Valid: true}
+	}
+	return row, nil
+}
+
+func scanSession(r interface{ Scan(...any) error }) (*Session, error) {
+	var row sessionRow
+	if err := r.Scan(
+		&row.id, &row.subjectID, &row.familyID, &row.createdAt, &row.expiresAt, &row.lastUsed,
+		&row.rotatedAt, &row.attrs, &row.roles, &row.subjectAttrs, &row.secretHash,
+		&row.authTime,
+	); err != nil {
+		return nil, err
+	}
+
+	sess := &Session{
+		ID:        row.id,
+		SubjectID: row.subjectID,
+		FamilyID:  row.familyID,
+		CreatedAt: time.Unix(0, row.createdAt),
+		ExpiresAt: time.Unix(0, row.expiresAt),
+		LastUsed:  time.Unix(0, row.lastUsed),
+	}
+	sess.AuthTime = sess.CreatedAt
+	if row.authTime != 0 {
+		sess.AuthTime = time.Unix(0, row.authTime)
+	}
+	secretHash, err := hex.DecodeString(row.secretHash)
+	if err != nil {
+		return nil, err
+	}
+	sess.SecretHash = secretHash
+	if row.rotatedAt.Valid {
+		sess.RotatedAt = time.Unix(0, row.rotatedAt.Int64)
+	}
+	if err := json.Unmarshal([]byte(row.attrs), &sess.Attrs); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(row.roles), &sess.Roles); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(row.subjectAttrs), &sess.SubjectAttrs); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+func expectOne(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("session not found")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package session_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/kararnab/authdemo/pkg/iam/session"
+	"github.com/kararnab/authdemo/pkg/iam/session/sessiontest"
+)
+
+func TestSQLStore(t *testing.T) {
+	sessiontest.RunStoreTests(t, func(t *testing.T) session.Store {
+		return newTestSQLStore(t)
+	})
+}
+
+func TestMigrateSQLIsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+
+	for range 2 {
+		if err := session.MigrateSQL(context.Background(), db, session.SQLite); err != nil {
+			t.Fatalf("MigrateSQL: %v", err)
+		}
+	}
+}
+
+// newTestSQLStore returns a store on a fresh, migrated in-memory database.
+func newTestSQLStore(t *testing.T) session.Store {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := session.MigrateSQL(context.Background(), db, session.SQLite); err != nil {
+		t.Fatalf("MigrateSQL: %v", err)
+	}
+	return session.NewSQLStore(db, session.SQLite)
+}
+
+// openTestDB opens a private in-memory SQLite database.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// Every connection to ":memory:" is a separate database: keep one.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
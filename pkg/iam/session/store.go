@@ -1,6 +1,9 @@
 package session
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Store defines the persistence contract for sessions.
 //
@@ -51,6 +54,34 @@ type Store interface {
 		sessionID string,
 	) error
 
+	// MarkRotated atomically flags a session as rotated.
+	//
+	// Expected behavior:
+	//   - Return ErrAlreadyRotated if the session was rotated before
+	//   - Exactly one concurrent caller may succeed
+	MarkRotated(
+		ctx context.Context,
+		sessionID string,
+		at time.Time,
+	) error
+
+	// DeleteByFamily removes every session of a rotation family.
+	//
+	// Used for refresh token reuse detection and logout.
+	DeleteByFamily(
+		ctx context.Context,
+		familyID string,
+	) error
+
+	// ListBySubject returns all unexpired sessions for a subject,
+	// including rotated ones (callers filter).
+	//
+	// Used for session listing ("where am I logged in?").
+	ListBySubject(
+		ctx context.Context,
+		subjectID string,
+	) ([]*Session, error)
+
 	// DeleteBySubject removes all sessions for a subject.
 	//
 	// Used for "logout everywhere".
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+)
+
+// Refresh token format:
+//
+//	<lookup ID>.<secret>
+//
+// Only the lookup ID and a SHA-256 digest of the secret are stored,
+// so a leaked store (DB dump, Redis snapshot) cannot be replayed.
+// The secret is 256 bits of randomness; a fast hash is sufficient.
+const refreshTokenSeparator = "."
+
+// newRefreshToken generates a lookup ID, a secret and the token handed to clients.
+func newRefreshToken() (id, secret, token string, err error) {
+
+	id, err = randomToken(16) // 128-bit lookup ID
+	if err != nil {
+		return "", "", "", err
+	}
+
+	secret, err = randomToken(32) // 256-bit secret
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return id, secret, id + refreshTokenSeparator + secret, nil
+}
+
+// parseRefreshToken splits a refresh token into lookup ID and secret.
+func parseRefreshToken(token string) (id, secret string, ok bool) {
+	id, secret, ok = strings.Cut(token, refreshTokenSeparator)
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// hashSecret returns the digest persisted for a refresh token secret.
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// secretMatches compares a presented secret with the stored digest
+// in constant time.
+func secretMatches(sess *Session, secret string) bool {
+	return len(sess.SecretHash) == sha256.Size &&
+		subtle.ConstantTimeCompare(sess.SecretHash, hashSecret(secret)) == 1
+}
+
+// randomToken creates a cryptographically secure, URL-safe random string.
+func randomToken(n int) (string, error) {
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
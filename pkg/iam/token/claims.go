@@ -0,0 +1,137 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Registered claim names shared by all token formats.
+const (
+	audienceClaim = "aud"
+	scopeClaim    = "scope" // space-delimited (RFC 8693 §4.2)
+	sessionClaim  = "sid"   // OpenID Connect session ID
+	actorClaim    = "act"   // RFC 8693 §4.1, nested per delegation hop
+	tokenIDClaim  = "jti"
+)
+
+// NewTokenID returns a random token ID ("jti").
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Lifetime returns the expiry of a token issued at now:
+// now+ttl, or the requested expiry if it is earlier.
+func Lifetime(
+	now time.Time,
+	ttl time.Duration,
+	requested time.Time,
+) time.Time {
+
+	exp := now.Add(ttl)
+	if !requested.IsZero() && requested.Before(exp) {
+		return requested
+	}
+	return exp
+}
+
+// SetRegistered adds jti, sid, act, aud and scope to a token payload.
+//
+// A single audience is encoded as a string, several as an array
+// (RFC 7519 §4.1.3).
+func SetRegistered(payload map[string]any, claims Claims) {
+	payload[tokenIDClaim] = claims.ID
+	if claims.SessionID != "" {
+		payload[sessionClaim] = claims.SessionID
+	}
+	if act := actorChain(claims.Actors); act != nil {
+		payload[actorClaim] = act
+	}
+
+	switch len(claims.Audience) {
+	case 0:
+	case 1:
+		payload[audienceClaim] = claims.Audience[0]
+	default:
+		payload[audienceClaim] = claims.Audience
+	}
+
+	if len(claims.Scopes) > 0 {
+		payload[scopeClaim] = strings.Join(claims.Scopes, " ")
+	}
+}
+
+// RegisteredFrom reads jti, sid, act, aud and scope from a decoded token payload.
+func RegisteredFrom(payload map[string]any, claims *Claims) {
+	claims.ID, _ = payload[tokenIDClaim].(string)
+	claims.SessionID, _ = payload[sessionClaim].(string)
+	claims.Actors = actorsFrom(payload[actorClaim])
+
+	switch aud := payload[audienceClaim].(type) {
+	case string:
+		claims.Audience = []string{aud}
+	case []any:
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				claims.Audience = append(claims.Audience, s)
+			}
+		}
+	}
+
+	if scope, ok := payload[scopeClaim].(string); ok {
+		claims.Scopes = strings.Fields(scope)
+	}
+}
+
+// maxActorDepth bounds the delegation chain read from a token.
+const maxActorDepth = 8
+
+// actorChain nests actors as {"sub": a0, "act": {"sub": a1, ...}}.
+func actorChain(actors []string) map[string]any {
+	var act map[string]any
+	for i := len(actors) - 1; i >= 0; i-- {
+		next := map[string]any{"sub": actors[i]}
+		if act != nil {
+			next[actorClaim] = act
+		}
+		act = next
+	}
+	return act
+}
+
+// actorsFrom flattens a nested "act" claim (current actor first).
+func actorsFrom(v any) []string {
+	var actors []string
+	for range maxActorDepth {
+		act, ok := v.(map[string]any)
+		if !ok {
+			break
+		}
+		sub, _ := act["sub"].(string)
+		actors = append(actors, sub)
+		v = act[actorClaim]
+	}
+	return actors
+}
+
+// HasAudience reports whether the token is meant for audience.
+// An empty audience means the verifier does not check it.
+func (c *Claims) HasAudience(audience string) bool {
+	return audience == "" || slices.Contains(c.Audience, audience)
+}
+
+// HasScopes reports whether every required scope was granted.
+func (c *Claims) HasScopes(required ...string) bool {
+	for _, s := range required {
+		if !slices.Contains(c.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
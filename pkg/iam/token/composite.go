@@ -0,0 +1,177 @@
+package token
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Format identifies how an access token is encoded.
+type Format string
+
+const (
+	FormatJWT            Format = "jwt"       // header.payload.signature
+	FormatPasetoV2Local  Format = "v2.local"  // encrypted, payload unreadable
+	FormatPasetoV4Public Format = "v4.public" // signed, payload readable
+	FormatOpaque         Format = "opaque"    // anything else (reference tokens)
+)
+
+// ed25519SignatureSize is the v4.public signature appended to the payload.
+const ed25519SignatureSize = 64
+
+// DetectFormat classifies a token by its prefix / structure.
+//
+// Detection only routes the token: the selected verifier still
+// fully validates it.
+func DetectFormat(accessToken string) Format {
+	switch {
+	case strings.HasPrefix(accessToken, "v2.local."):
+		return FormatPasetoV2Local
+	case strings.HasPrefix(accessToken, "v4.public."):
+		return FormatPasetoV4Public
+	case strings.Count(accessToken, ".") == 2 && strings.HasPrefix(accessToken, "eyJ"):
+		// base64url('{"') — a JSON JOSE header
+		return FormatJWT
+	default:
+		return FormatOpaque
+	}
+}
+
+// Route sends tokens of one format (and, for formats with a readable
+// payload, one issuer) to a verifier with its own keys.
+type Route struct {
+	Format Format
+
+	// Issuer ("iss") served by this route; empty matches any issuer.
+	// Only JWT and v4.public tokens expose iss before verification;
+	// routes for other formats must leave it empty.
+	Issuer string
+
+	// Verifier validates the token (e.g. a MultiVerifier over the
+	// issuer's keys.Provider, or a remote JWKS provider).
+	Verifier Verifier
+
+	// Mapper (optional) adapts claims of external issuers.
+	Mapper ClaimMapper
+
+	// Until (optional) sunsets the route: from then on its tokens are
+	// rejected. Zero keeps the route open.
+	Until time.Time
+}
+
+// ClaimMapper adapts the claims of a trusted external issuer to this
+// system (subject namespace, roles, attributes).
+type ClaimMapper interface {
+	// MapClaims rewrites claims; payload is the verified token payload
+	// (nil for formats whose payload is not readable).
+	MapClaims(payload map[string]any, claims *Claims) error
+}
+
+// CompositeVerifier verifies tokens of several formats and issuers at
+// once, e.g. while migrating from JWT to PASETO (tokens issued before
+// the switch stay valid until they expire) or to also accept tokens
+// from external OIDC identity providers.
+//
+// Routing:
+//   - format detected by prefix / structure
+//   - for JWT / v4.public, the unverified "iss" picks the route
+//     (exact issuer first, then an issuer-agnostic route)
+//   - no matching route, or a route past its Until → rejected
+type CompositeVerifier struct {
+	Routes []Route
+}
+
+// Verify implements Verifier.
+func (c *CompositeVerifier) Verify(
+	ctx context.Context,
+	accessToken string,
+) (*Claims, error) {
+
+	format := DetectFormat(accessToken)
+	payload := readablePayload(format, accessToken)
+	iss, _ := payload["iss"].(string)
+
+	route, ok := c.route(format, iss)
+	if !ok {
+		return nil, errors.New("token: unsupported token format or issuer")
+	}
+	if !route.Until.IsZero() && !time.Now().Before(route.Until) {
+		return nil, errors.New("token: token format no longer accepted")
+	}
+
+	claims, err := route.Verifier.Verify(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if route.Mapper != nil {
+		// The signature has been verified: the payload is now trusted.
+		if err := route.Mapper.MapClaims(payload, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// route selects the route for a token.
+func (c *CompositeVerifier) route(format Format, iss string) (Route, bool) {
+	var fallback *Route
+
+	for i := range c.Routes {
+		r := &c.Routes[i]
+		if r.Format != format {
+			continue
+		}
+		if r.Issuer != "" && iss != "" && r.Issuer == iss {
+			return *r, true
+		}
+		if r.Issuer == "" && fallback == nil {
+			fallback = r
+		}
+	}
+
+	if fallback == nil {
+		return Route{}, false
+	}
+	return *fallback, true
+}
+
+// readablePayload decodes the payload of JWT / v4.public tokens
+// WITHOUT verifying them (nil for other formats or malformed tokens).
+func readablePayload(format Format, accessToken string) map[string]any {
+	var raw []byte
+
+	switch format {
+	case FormatJWT:
+		parts := strings.Split(accessToken, ".")
+		b, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil
+		}
+		raw = b
+
+	case FormatPasetoV4Public:
+		parts := strings.Split(accessToken, ".")
+		if len(parts) < 3 {
+			return nil
+		}
+		b, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil || len(b) < ed25519SignatureSize {
+			return nil
+		}
+		raw = b[:len(b)-ed25519SignatureSize]
+
+	default:
+		return nil
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil
+	}
+	return payload
+}
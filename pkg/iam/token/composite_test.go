@@ -0,0 +1,196 @@
+package token_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/jwt"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/iam/token/paseto"
+)
+
+const (
+	issuerA = "https://a.example.com"
+	issuerB = "https://b.example.com"
+)
+
+// prefixMapper namespaces subjects of an external issuer.
+type prefixMapper string
+
+func (m prefixMapper) MapClaims(_ map[string]any, claims *token.Claims) error {
+	claims.SubjectID = string(m) + claims.SubjectID
+	return nil
+}
+
+func TestCompositeVerifierRouting(t *testing.T) {
+	keyA := newKey(t, "a1", keys.ES256)
+	keyB := newKey(t, "b1", keys.EdDSA)
+	keyPub := newKey(t, "p1", keys.EdDSA)
+	keyLocal := newKey(t, "l1", keys.HS256)
+
+	v := &token.CompositeVerifier{
+		Routes: []token.Route{
+			jwtRoute(issuerA, keyA),
+			{
+				Format: token.FormatJWT,
+				Issuer: issuerB,
+				Verifier: &token.MultiVerifier{
+					Verifier:    jwt.NewVerifier(issuerB, ""),
+					KeyProvider: keys.NewMemoryProvider(keyB),
+				},
+				Mapper: prefixMapper("b|"),
+			},
+			{
+				Format: token.FormatPasetoV4Public,
+				Verifier: &token.MultiVerifier{
+					Verifier:    paseto.NewPublicVerifier(issuerA, ""),
+					KeyProvider: keys.NewMemoryProvider(keyPub),
+				},
+			},
+		},
+	}
+
+	jwtA := issueWith(t, mustIssuer(jwt.NewIssuer(keys.NewMemoryProvider(keyA), issuerA, time.Minute)))
+	jwtB := issueWith(t, mustIssuer(jwt.NewIssuer(keys.NewMemoryProvider(keyB), issuerB, time.Minute)))
+	pub := issueWith(t, mustIssuer(paseto.NewPublicIssuer(keys.NewMemoryProvider(keyPub), issuerA, time.Minute)))
+	local := issueWith(t, mustIssuer(paseto.NewIssuer(keys.NewMemoryProvider(keyLocal), issuerA, time.Minute)))
+
+	// Signed with issuer A's key, but claiming an issuer with no route.
+	unknownIssuer := issueWith(t, mustIssuer(jwt.NewIssuer(keys.NewMemoryProvider(keyA), "https://evil.example.com", time.Minute)))
+	// Claiming issuer B, signed with issuer A's key.
+	wrongKey := issueWith(t, mustIssuer(jwt.NewIssuer(keys.NewMemoryProvider(keyA), issuerB, time.Minute)))
+
+	tests := []struct {
+		name    string
+		token   string
+		subject string // empty: rejected
+	}{
+		{"JWTIssuerA", jwtA, "user-1"},
+		{"JWTIssuerBMapped", jwtB, "b|user-1"},
+		{"PasetoV4Public", pub, "user-1"},
+		{"FormatWithoutRoute", local, ""},
+		{"UnknownIssuer", unknownIssuer, ""},
+		{"IssuerWithOtherIssuersKey", wrongKey, ""},
+		{"Opaque", "not-a-token", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), tt.token)
+			if tt.subject == "" {
+				if err == nil {
+					t.Fatalf("Verify accepted the token (sub %q)", claims.SubjectID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.SubjectID != tt.subject {
+				t.Fatalf("sub = %q, want %q", claims.SubjectID, tt.subject)
+			}
+		})
+	}
+}
+
+func TestCompositeVerifierIssuerAgnosticFallback(t *testing.T) {
+	keyA := newKey(t, "a1", keys.ES256)
+	keyAny := newKey(t, "x1", keys.ES256)
+
+	fallback := jwtRoute("", keyAny)
+	fallback.Verifier = &token.MultiVerifier{
+		Verifier:    jwt.NewVerifier("https://any.example.com", ""),
+		KeyProvider: keys.NewMemoryProvider(keyAny),
+	}
+
+	v := &token.CompositeVerifier{
+		Routes: []token.Route{fallback, jwtRoute(issuerA, keyA)},
+	}
+
+	// The exact issuer route wins over an earlier issuer-agnostic one.
+	tknA := issueWith(t, mustIssuer(jwt.NewIssuer(keys.NewMemoryProvider(keyA), issuerA, time.Minute)))
+	if _, err := v.Verify(context.Background(), tknA); err != nil {
+		t.Fatalf("Verify (exact issuer): %v", err)
+	}
+
+	// Other issuers go to the fallback.
+	tknAny := issueWith(t, mustIssuer(jwt.NewIssuer(keys.NewMemoryProvider(keyAny), "https://any.example.com", time.Minute)))
+	if _, err := v.Verify(context.Background(), tknAny); err != nil {
+		t.Fatalf("Verify (fallback): %v", err)
+	}
+}
+
+func TestCompositeVerifierRouteSunset(t *testing.T) {
+	key := newKey(t, "a1", keys.ES256)
+	tkn := issueWith(t, mustIssuer(jwt.NewIssuer(keys.NewMemoryProvider(key), issuerA, time.Minute)))
+
+	open := jwtRoute(issuerA, key)
+	open.Until = time.Now().Add(time.Hour)
+	if _, err := (&token.CompositeVerifier{Routes: []token.Route{open}}).Verify(context.Background(), tkn); err != nil {
+		t.Fatalf("Verify before sunset: %v", err)
+	}
+
+	closed := jwtRoute(issuerA, key)
+	closed.Until = time.Now().Add(-time.Second)
+	if _, err := (&token.CompositeVerifier{Routes: []token.Route{closed}}).Verify(context.Background(), tkn); err == nil {
+		t.Fatal("Verify accepted a token of a route past its sunset")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]token.Format{
+		"eyJhbGciOiJFUzI1NiJ9.eyJzdWIiOiJ1In0.c2ln": token.FormatJWT,
+		"v2.local.abc":  token.FormatPasetoV2Local,
+		"v4.public.abc": token.FormatPasetoV4Public,
+		"a.b.c":         token.FormatOpaque,
+		"random-handle": token.FormatOpaque,
+	}
+	for tkn, want := range tests {
+		if got := token.DetectFormat(tkn); got != want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tkn, got, want)
+		}
+	}
+}
+
+func jwtRoute(issuer string, k keys.Key) token.Route {
+	return token.Route{
+		Format: token.FormatJWT,
+		Issuer: issuer,
+		Verifier: &token.MultiVerifier{
+			Verifier:    jwt.NewVerifier(issuer, ""),
+			KeyProvider: keys.NewMemoryProvider(k),
+		},
+	}
+}
+
+func newKey(t *testing.T, id string, alg keys.Algorithm) keys.Key {
+	t.Helper()
+
+	k, err := keys.Generate(id, alg)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return k
+}
+
+// mustIssuer adapts the (issuer, error) constructors.
+func mustIssuer[I token.Issuer](iss I, err error) func(t *testing.T) token.Issuer {
+	return func(t *testing.T) token.Issuer {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("NewIssuer: %v", err)
+		}
+		return iss
+	}
+}
+
+func issueWith(t *testing.T, newIssuer func(t *testing.T) token.Issuer) string {
+	t.Helper()
+
+	tkn, err := newIssuer(t).Issue(context.Background(), token.Claims{SubjectID: "user-1"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return tkn
+}
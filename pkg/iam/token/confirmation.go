@@ -0,0 +1,28 @@
+package token
+
+// Confirmation ("cnf", RFC 7800) binds an access token to a key.
+//
+// Only the DPoP form is used here (RFC 9449):
+//
+//	"cnf": {"jkt": "<base64url SHA-256 JWK thumbprint>"}
+const confirmationClaim = "cnf"
+
+// SetConfirmation adds the cnf claim to a token payload
+// (no-op for unbound tokens).
+func SetConfirmation(payload map[string]any, jkt string) {
+	if jkt == "" {
+		return
+	}
+	payload[confirmationClaim] = map[string]string{"jkt": jkt}
+}
+
+// ConfirmationFrom extracts cnf.jkt from a decoded token payload
+// ("" for bearer tokens).
+func ConfirmationFrom(payload map[string]any) string {
+	cnf, ok := payload[confirmationClaim].(map[string]any)
+	if !ok {
+		return ""
+	}
+	jkt, _ := cnf["jkt"].(string)
+	return jkt
+}
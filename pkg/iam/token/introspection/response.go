@@ -0,0 +1,104 @@
+// Package introspection implements OAuth 2.0 Token Introspection
+// (RFC 7662): the wire format shared by the IAM endpoint and a
+// token.Verifier for resource servers that validate tokens remotely.
+package introspection
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+)
+
+// Response is an introspection response (RFC 7662 §2.2).
+//
+// roles / attrs are extension members carrying the subject context
+// resource servers need for authorization.
+type Response struct {
+	Active    bool              `json:"active"`
+	Scope     string            `json:"scope,omitempty"`
+	TokenType string            `json:"token_type,omitempty"` // "Bearer" or "DPoP"
+	Exp       int64             `json:"exp,omitempty"`
+	Iat       int64             `json:"iat,omitempty"`
+	Sub       string            `json:"sub,omitempty"`
+	Aud       Audience          `json:"aud,omitempty"`
+	Jti       string            `json:"jti,omitempty"`
+	Sid       string            `json:"sid,omitempty"`
+	Act       *Actor            `json:"act,omitempty"`
+	Cnf       *Confirmation     `json:"cnf,omitempty"`
+	Roles     []string          `json:"roles,omitempty"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+}
+
+// Confirmation is the "cnf" member of DPoP-bound tokens (RFC 9449 §6.2).
+type Confirmation struct {
+	JKT string `json:"jkt"`
+}
+
+// Actor is the "act" member of exchanged tokens (RFC 8693 §4.1):
+// the current actor, with earlier actors nested under Act.
+type Actor struct {
+	Sub string `json:"sub"`
+	Act *Actor `json:"act,omitempty"`
+}
+
+// NewActor nests a delegation chain (current actor first);
+// nil for an empty chain.
+func NewActor(actors []string) *Actor {
+	var act *Actor
+	for i := len(actors) - 1; i >= 0; i-- {
+		act = &Actor{Sub: actors[i], Act: act}
+	}
+	return act
+}
+
+// Chain flattens the actor chain (current actor first).
+func (a *Actor) Chain() []string {
+	var actors []string
+	for ; a != nil; a = a.Act {
+		actors = append(actors, a.Sub)
+	}
+	return actors
+}
+
+// Audience is "aud": a single string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON accepts both forms of "aud".
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Claims converts an active response back to token claims.
+func (r *Response) Claims() *token.Claims {
+	c := &token.Claims{
+		SubjectID: r.Sub,
+		Roles:     r.Roles,
+		Attrs:     r.Attrs,
+		Audience:  r.Aud,
+		Scopes:    strings.Fields(r.Scope),
+		Actors:    r.Act.Chain(),
+		ID:        r.Jti,
+		SessionID: r.Sid,
+		IssuedAt:  time.Unix(r.Iat, 0),
+		ExpiresAt: time.Unix(r.Exp, 0),
+	}
+
+	if r.Cnf != nil {
+		c.Confirmation = r.Cnf.JKT
+	}
+
+	return c
+}
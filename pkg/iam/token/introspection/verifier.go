@@ -0,0 +1,121 @@
+package introspection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+)
+
+// maxResponseSize bounds introspection responses read into memory.
+const maxResponseSize = 1 << 20
+
+// Options configures an introspecting Verifier.
+type Options struct {
+	// Endpoint is the IAM introspection URL
+	// (e.g. "https://iam.example.com/oauth/introspect").
+	Endpoint string
+
+	// ClientID / ClientSecret authenticate this resource server
+	// (HTTP Basic).
+	ClientID     string
+	ClientSecret string
+
+	// Audience is the "aud" value this service expects;
+	// empty disables the audience check.
+	Audience string
+
+	// HTTPClient defaults to a client with a 5s timeout.
+	HTTPClient *http.Client
+}
+
+// Verifier implements token.Verifier by asking the IAM (RFC 7662).
+//
+// It lets resource servers accept any token format the IAM issues,
+// including opaque tokens, without holding keys or token stores.
+//
+// TODO:
+//   - Response caching (bounded by exp) to avoid a round trip per request
+type Verifier struct {
+	opts Options
+}
+
+// NewVerifier creates an introspecting verifier.
+func NewVerifier(opts Options) (*Verifier, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("introspection: endpoint is required")
+	}
+	if opts.ClientID == "" || opts.ClientSecret == "" {
+		return nil, errors.New("introspection: client credentials are required")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	return &Verifier{opts: opts}, nil
+}
+
+// Verify implements token.Verifier.
+func (v *Verifier) Verify(
+	ctx context.Context,
+	accessToken string,
+) (*token.Claims, error) {
+
+	form := url.Values{
+		"token":           {accessToken},
+		"token_type_hint": {"access_token"},
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		v.opts.Endpoint,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(v.opts.ClientID), url.QueryEscape(v.opts.ClientSecret))
+
+	resp, err := v.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("introspection: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection: unexpected status %d", resp.StatusCode)
+	}
+
+	var ir Response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&ir); err != nil {
+		return nil, errors.New("introspection: invalid response")
+	}
+
+	if !ir.Active {
+		return nil, errors.New("introspection: token inactive")
+	}
+
+	claims := ir.Claims()
+
+	// The IAM checks expiry too; this guards against clock skew
+	// and responses served from caches.
+	if ir.Exp != 0 && time.Now().After(claims.ExpiresAt) {
+		return nil, errors.New("introspection: token expired")
+	}
+
+	if !claims.HasAudience(v.opts.Audience) {
+		return nil, errors.New("introspection: invalid audience")
+	}
+
+	return claims, nil
+}
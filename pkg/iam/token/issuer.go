@@ -1,6 +1,9 @@
 package token
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Claims represents the canonical (normalized) claims embedded in an access token.
 //
@@ -9,6 +12,36 @@ type Claims struct {
 	SubjectID string            // internal subject identifier
 	Roles     []string          // optional coarse-grained roles
 	Attrs     map[string]string // optional attributes (org, tier, etc)
+
+	// Audience ("aud") lists the services the token is meant for.
+	// Verifiers configured with an audience reject tokens for others.
+	Audience []string
+
+	// Scopes ("scope", space-delimited) are the permissions granted
+	// to the token, on top of what the subject's roles allow.
+	Scopes []string
+
+	// Actors ("act", RFC 8693) is the delegation chain of an
+	// exchanged token: the party currently acting on behalf of the
+	// subject first, earlier actors after it. Empty for tokens used
+	// by the subject itself.
+	Actors []string
+
+	// SessionID ("sid") is the login (session family) the token was
+	// issued for; revoking the session revokes its access tokens.
+	SessionID string
+
+	// Confirmation is the DPoP key thumbprint (cnf.jkt) of a
+	// sender-constrained token; empty for bearer tokens.
+	Confirmation string
+
+	// Set by issuers (and returned by verifiers):
+	//   - ID: unique token ID ("jti"), generated when empty
+	//   - IssuedAt / ExpiresAt: "iat" / "exp"; a non-zero ExpiresAt
+	//     passed to an issuer can only shorten the token lifetime
+	ID        string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
 }
 
 // Issuer is responsible for minting/issuing access tokens.
@@ -28,10 +61,15 @@ type Issuer interface {
 
 	// Issue generates a signed or encrypted access token.
 	//
+	// Expected behavior:
+	//   - Embed audience, scopes, sid, act and cnf when set in claims
+	//   - Generate jti and set iat / exp (claims.ExpiresAt may only shorten it)
+	//   - Key-based formats sign (or encrypt) with the key provider's
+	//     active key at call time and name it in the kid, so key
+	//     rotation applies to the next token
+	//
 	// TODO:
-	//   - Audience support
-	//   - Scope / permission embedding
-	//   - Key rotation strategies, Token versioning
+	//   - Token versioning
 	Issue(
 		ctx context.Context,
 		claims Claims,
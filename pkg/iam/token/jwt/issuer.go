@@ -2,36 +2,47 @@ package jwt
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	jwtlib "github.com/golang-jwt/jwt/v5"
 
 	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
 )
 
-// Issuer issues JWT access tokens using a single signing key.
+// Issuer issues JWT access tokens signed with the active key of a
+// keys.Provider.
+//
+// The active key is read on every Issue, so rotation takes effect
+// immediately; "kid" and signature always come from the same key.
+//
+// Supported algorithms: HS256, RS256, ES256, EdDSA.
 type Issuer struct {
-	key    []byte
-	keyID  string // kid
+	keys   keys.Provider
 	issuer string
 	ttl    time.Duration
 }
 
 // NewIssuer creates a JWT issuer.
 //
-// keyID is embedded as "kid" in JWT header (for rotation).
+// The active key must be usable for signing (asymmetric keys MUST
+// carry their private half); this is checked now and on every Issue.
 func NewIssuer(
-	key []byte,
-	keyID string,
+	kp keys.Provider,
 	issuer string,
 	ttl time.Duration,
-) *Issuer {
+) (*Issuer, error) {
+
+	if _, _, err := signingKey(kp.ActiveKey()); err != nil {
+		return nil, err
+	}
+
 	return &Issuer{
-		key:    key,
-		keyID:  keyID,
+		keys:   kp,
 		issuer: issuer,
 		ttl:    ttl,
-	}
+	}, nil
 }
 
 // Issue implements token.Issuer.
@@ -40,14 +51,30 @@ func (i *Issuer) Issue(
 	claims token.Claims,
 ) (string, error) {
 
+	// One snapshot: a concurrent rotation cannot mix kid and key.
+	key := i.keys.ActiveKey()
+	method, signKey, err := signingKey(key)
+	if err != nil {
+		return "", err
+	}
+
 	now := time.Now()
 
+	if claims.ID == "" {
+		id, err := token.NewTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.ID = id
+	}
+
 	jwtClaims := jwtlib.MapClaims{
 		"iss": i.issuer,
 		"sub": claims.SubjectID,
 		"iat": now.Unix(),
-		"exp": now.Add(i.ttl).Unix(),
+		"exp": token.Lifetime(now, i.ttl, claims.ExpiresAt).Unix(),
 	}
+	token.SetRegistered(jwtClaims, claims)
 
 	if len(claims.Roles) > 0 {
 		jwtClaims["roles"] = claims.Roles
@@ -55,14 +82,56 @@ func (i *Issuer) Issue(
 	if len(claims.Attrs) > 0 {
 		jwtClaims["attrs"] = claims.Attrs
 	}
+	token.SetConfirmation(jwtClaims, claims.Confirmation)
 
 	t := jwtlib.NewWithClaims(
-		jwtlib.SigningMethodHS256,
+		method,
 		jwtClaims,
 	)
 
 	// 🔑 Key rotation support
-	t.Header["kid"] = i.keyID
+	t.Header["kid"] = key.ID
+
+	return t.SignedString(signKey)
+}
+
+// signingKey returns the signing method and key material of key:
+// []byte (HMAC) or crypto.Signer.
+func signingKey(key keys.Key) (jwtlib.SigningMethod, any, error) {
+	if key.ID == "" {
+		return nil, nil, errors.New("jwt: no active signing key")
+	}
 
-	return t.SignedString(i.key)
+	method, err := signingMethod(key.Alg())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if key.IsSymmetric() {
+		if len(key.Key) == 0 {
+			return nil, nil, errors.New("jwt: empty signing key")
+		}
+		return method, key.Key, nil
+	}
+
+	if key.PrivateKey == nil {
+		return nil, nil, errors.New("jwt: private key required for " + string(key.Alg()))
+	}
+	return method, key.PrivateKey, nil
+}
+
+// signingMethod maps a key algorithm to its JWS signing method.
+func signingMethod(alg keys.Algorithm) (jwtlib.SigningMethod, error) {
+	switch alg {
+	case keys.HS256:
+		return jwtlib.SigningMethodHS256, nil
+	case keys.RS256:
+		return jwtlib.SigningMethodRS256, nil
+	case keys.ES256:
+		return jwtlib.SigningMethodES256, nil
+	case keys.EdDSA:
+		return jwtlib.SigningMethodEdDSA, nil
+	default:
+		return nil, errors.New("jwt: unsupported algorithm " + string(alg))
+	}
 }
@@ -0,0 +1,25 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/jwt"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/iam/token/tokentest"
+)
+
+func TestIssuerRotation(t *testing.T) {
+	for _, alg := range []keys.Algorithm{keys.HS256, keys.RS256, keys.ES256, keys.EdDSA} {
+		t.Run(string(alg), func(t *testing.T) {
+			tokentest.RunIssuerRotationTests(t, tokentest.IssuerSuite{
+				Algorithm: alg,
+				NewIssuer: func(kp keys.Provider) (token.Issuer, error) {
+					return jwt.NewIssuer(kp, "test", time.Minute)
+				},
+				Verifier: jwt.NewVerifier("test", ""),
+			})
+		})
+	}
+}
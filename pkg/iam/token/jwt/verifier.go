@@ -8,39 +8,75 @@ import (
 	jwtlib "github.com/golang-jwt/jwt/v5"
 
 	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
 )
 
 // Verifier verifies JWT access tokens using a single key.
 type Verifier struct {
 	Issuer string
+
+	// Audience is the "aud" value this service expects;
+	// empty disables the audience check.
+	Audience string
 }
 
 // NewVerifier creates a JWT verifier.
 func NewVerifier(
 	issuer string,
+	audience string,
 ) *Verifier {
 	return &Verifier{
-		Issuer: issuer,
+		Issuer:   issuer,
+		Audience: audience,
+	}
+}
+
+// KeyID implements token.KeyIDExtractor.
+//
+// The header is decoded WITHOUT verifying the signature.
+func (v *Verifier) KeyID(accessToken string) (string, bool) {
+	parsed, _, err := jwtlib.NewParser().ParseUnverified(accessToken, jwtlib.MapClaims{})
+	if err != nil {
+		return "", false
+	}
+
+	kid, ok := parsed.Header["kid"].(string)
+	if !ok || kid == "" {
+		return "", false
 	}
+	return kid, true
 }
 
-// VerifyWithKey verifies a JWT using the provided signing key.
+// VerifyWithKey verifies a JWT using the provided key.
+//
+// The token "alg" must match the key algorithm; this prevents
+// algorithm confusion (e.g. HS256 signed with a public key).
 func (v *Verifier) VerifyWithKey(
 	ctx context.Context,
 	accessToken string,
-	key []byte,
+	key keys.Key,
 ) (*token.Claims, error) {
 
+	verifyKey, err := verificationKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []jwtlib.ParserOption{
+		jwtlib.WithValidMethods([]string{string(key.Alg())}),
+		jwtlib.WithIssuer(v.Issuer),
+		jwtlib.WithExpirationRequired(),
+	}
+	if v.Audience != "" {
+		opts = append(opts, jwtlib.WithAudience(v.Audience))
+	}
+
 	parsed, err := jwtlib.Parse(
 		accessToken,
 		func(t *jwtlib.Token) (any, error) {
-			if _, ok := t.Method.(*jwtlib.SigningMethodHMAC); !ok {
-				return nil, errors.New("jwt: unexpected signing method")
-			}
-			return key, nil
+			return verifyKey, nil
 		},
-		// jwtlib.WithAudience(nil), // audience optional for MVP
-		jwtlib.WithIssuer(v.Issuer),
+		opts...,
 	)
 	if err != nil || !parsed.Valid {
 		return nil, errors.New("jwt: invalid token")
@@ -52,11 +88,11 @@ func (v *Verifier) VerifyWithKey(
 	}
 
 	// Expiry check (defensive)
-	if exp, ok := claimsMap["exp"].(float64); ok {
-		if time.Now().After(time.Unix(int64(exp), 0)) {
-			return nil, errors.New("jwt: token expired")
-		}
+	exp, ok := claimsMap["exp"].(float64)
+	if !ok || time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("jwt: token expired")
 	}
+	iat, _ := claimsMap["iat"].(float64)
 
 	sub, _ := claimsMap["sub"].(string)
 
@@ -80,9 +116,29 @@ func (v *Verifier) VerifyWithKey(
 		}
 	}
 
-	return &token.Claims{
-		SubjectID: sub,
-		Roles:     roles,
-		Attrs:     attrs,
-	}, nil
+	claims := &token.Claims{
+		SubjectID:    sub,
+		Roles:        roles,
+		Attrs:        attrs,
+		Confirmation: token.ConfirmationFrom(claimsMap),
+		IssuedAt:     time.Unix(int64(iat), 0),
+		ExpiresAt:    time.Unix(int64(exp), 0),
+	}
+	token.RegisteredFrom(claimsMap, claims)
+
+	return claims, nil
+}
+
+// verificationKey returns the key material expected by jwtlib for key.
+func verificationKey(key keys.Key) (any, error) {
+	if key.IsSymmetric() {
+		if len(key.Key) == 0 {
+			return nil, errors.New("jwt: empty verification key")
+		}
+		return key.Key, nil
+	}
+	if key.PublicKey == nil {
+		return nil, errors.New("jwt: missing public key")
+	}
+	return key.PublicKey, nil
 }
@@ -0,0 +1,63 @@
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// rsaKeyBits is the modulus size used for generated RS256 keys.
+const rsaKeyBits = 2048
+
+// Generate creates a fresh key for the given algorithm.
+//
+// HS256 keys are 32 random bytes (also valid as PASETO local keys).
+// Asymmetric keys carry both halves.
+func Generate(id string, alg Algorithm) (Key, error) {
+	switch alg {
+	case "", HS256:
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return Key{}, err
+		}
+		return Key{ID: id, Algorithm: HS256, Key: b}, nil
+
+	case RS256:
+		priv, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{ID: id, Algorithm: RS256, PrivateKey: priv, PublicKey: &priv.PublicKey}, nil
+
+	case ES256:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{ID: id, Algorithm: ES256, PrivateKey: priv, PublicKey: &priv.PublicKey}, nil
+
+	case EdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{ID: id, Algorithm: EdDSA, PrivateKey: priv, PublicKey: pub}, nil
+
+	default:
+		return Key{}, errors.New("keys: unsupported algorithm " + string(alg))
+	}
+}
+
+// NewID returns a unique key ID that sorts by creation time,
+// e.g. "jwt-20260102T150405Z-9f86d081".
+func NewID(prefix string) string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix) // never fails (crypto/rand)
+
+	return prefix + "-" + time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,214 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// JWK is the public JSON Web Key representation (RFC 7517) of a Key.
+//
+// Only public parameters are modelled; private or symmetric
+// material is never serialized.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC / OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK returns the public JWK for an asymmetric key.
+func PublicJWK(k Key) (JWK, error) {
+	if k.IsSymmetric() {
+		return JWK{}, errors.New("keys: symmetric keys cannot be published")
+	}
+
+	jwk := JWK{
+		Kid: k.ID,
+		Use: "sig",
+		Alg: string(k.Alg()),
+	}
+
+	switch pub := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return JWK{}, errors.New("keys: unsupported EC curve")
+		}
+		raw, err := pub.Bytes() // 0x04 || X || Y
+		if err != nil {
+			return JWK{}, err
+		}
+		size := (len(raw) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = b64(raw[1 : 1+size])
+		jwk.Y = b64(raw[1+size:])
+
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64(pub)
+
+	default:
+		return JWK{}, errors.New("keys: unsupported public key type")
+	}
+
+	return jwk, nil
+}
+
+// PublicJWKS builds the JWKS document for a provider.
+//
+// Symmetric keys are skipped: they can never be shared with verifiers.
+func PublicJWKS(p Provider) JWKS {
+	set := JWKS{Keys: []JWK{}}
+	if p == nil {
+		return set
+	}
+
+	for _, k := range p.VerificationKeys() {
+		if k.IsSymmetric() {
+			continue
+		}
+		jwk, err := PublicJWK(k)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// PublicKey decodes the public key described by the JWK.
+//
+// Supported: RSA, EC P-256 and OKP Ed25519 (the algorithms this
+// repo signs with).
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := unb64(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := unb64(j.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("keys: invalid RSA JWK")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, errors.New("keys: unsupported EC curve")
+		}
+		x, err := unb64(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := unb64(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("keys: invalid EC JWK")
+		}
+		// Rejects points that are not on the curve.
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, errors.New("keys: unsupported OKP curve")
+		}
+		x, err := unb64(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("keys: invalid Ed25519 JWK")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, errors.New("keys: unsupported JWK key type")
+	}
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint (base64url).
+//
+// Only the required members take part, in lexicographic order,
+// so kid / use / alg do not change the thumbprint.
+func (j JWK) Thumbprint() (string, error) {
+	var members any
+
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+
+	default:
+		return "", errors.New("keys: unsupported JWK key type")
+	}
+
+	raw, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(raw)
+	return b64(sum[:]), nil
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func unb64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
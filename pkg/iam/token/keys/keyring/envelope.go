@@ -0,0 +1,166 @@
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/kms"
+)
+
+// seal envelope-encrypts a key: its material is encrypted with a fresh
+// data key from the KMS, stored wrapped by the KMS key kekID.
+//
+// The key ID and algorithm are bound as associated data, so records
+// cannot be swapped or relabelled in the store.
+func seal(
+	ctx context.Context,
+	k kms.KMS,
+	kekID string,
+	key keys.Key,
+) (Record, error) {
+
+	material, err := marshalMaterial(key)
+	if err != nil {
+		return Record{}, err
+	}
+
+	dek, wrapped, err := k.GenerateDataKey(ctx, kekID, dekAAD(key.ID))
+	if err != nil {
+		return Record{}, fmt.Errorf("keyring: generate data key: %w", err)
+	}
+
+	ciphertext, err := encrypt(dek, material, materialAAD(key.ID, key.Alg()))
+	if err != nil {
+		return Record{}, err
+	}
+
+	return Record{
+		ID:         key.ID,
+		Algorithm:  key.Alg(),
+		WrappedKey: wrapped,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// open decrypts a record sealed by seal.
+func open(
+	ctx context.Context,
+	k kms.KMS,
+	kekID string,
+	rec Record,
+) (keys.Key, error) {
+
+	dek, err := k.Decrypt(ctx, kekID, rec.WrappedKey, dekAAD(rec.ID))
+	if err != nil {
+		return keys.Key{}, fmt.Errorf("keyring: cannot unwrap key %q (wrong KMS key?): %w", rec.ID, err)
+	}
+
+	material, err := decrypt(dek, rec.Ciphertext, materialAAD(rec.ID, rec.Algorithm))
+	if err != nil {
+		return keys.Key{}, fmt.Errorf("keyring: cannot decrypt key %q", rec.ID)
+	}
+
+	return unmarshalMaterial(rec.ID, rec.Algorithm, material)
+}
+
+// marshalMaterial serializes the secret half of a key:
+// raw bytes for symmetric keys, PKCS#8 DER for private keys.
+func marshalMaterial(k keys.Key) ([]byte, error) {
+	if k.IsSymmetric() {
+		if len(k.Key) == 0 {
+			return nil, errors.New("keyring: empty symmetric key")
+		}
+		return k.Key, nil
+	}
+	if k.PrivateKey == nil {
+		return nil, errors.New("keyring: private key required for " + string(k.Alg()))
+	}
+	return x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+}
+
+// unmarshalMaterial rebuilds a key (and its public half).
+func unmarshalMaterial(id string, alg keys.Algorithm, material []byte) (keys.Key, error) {
+	k := keys.Key{ID: id, Algorithm: alg}
+
+	if k.IsSymmetric() {
+		k.Key = material
+		return k, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(material)
+	if err != nil {
+		return keys.Key{}, fmt.Errorf("keyring: invalid private key %q", id)
+	}
+
+	var pub crypto.PublicKey
+	switch priv := parsed.(type) {
+	case *rsa.PrivateKey:
+		pub = &priv.PublicKey
+	case *ecdsa.PrivateKey:
+		pub = &priv.PublicKey
+	case ed25519.PrivateKey:
+		pub = priv.Public()
+	default:
+		return keys.Key{}, fmt.Errorf("keyring: unsupported private key %q", id)
+	}
+
+	k.PrivateKey = parsed.(crypto.Signer)
+	k.PublicKey = pub
+	return k, nil
+}
+
+func dekAAD(id string) []byte {
+	return []byte("authdemo-keyring:dek:" + id)
+}
+
+func materialAAD(id string, alg keys.Algorithm) []byte {
+	return []byte("authdemo-keyring:key:" + id + ":" + string(alg))
+}
+
+// encrypt is AES-256-GCM; the nonce is prepended to the ciphertext.
+func encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func decrypt(key, sealed, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("keyring: ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("keyring: AES-256 key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
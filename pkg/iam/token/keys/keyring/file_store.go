@@ -0,0 +1,154 @@
+package keyring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileFormatVersion is bumped on incompatible changes of the file layout.
+const fileFormatVersion = 1
+
+// staleLockAge is how old a lock file must be to be considered left
+// behind by a crashed writer (writes take milliseconds).
+const staleLockAge = 30 * time.Second
+
+// fileDocument is the on-disk JSON layout.
+type fileDocument struct {
+	Version    int      `json:"version"`
+	Generation int64    `json:"generation,omitempty"` // 0 in files written before
+	Keys       []Record `json:"keys"`
+}
+
+// fileStore keeps the keyring in one JSON file.
+//
+// Writes go to a temporary file in the same directory which is
+// fsynced and renamed over the old one, so a crash leaves either the
+// old or the new keyring. Writers on the same host take a lock file
+// (<path>.lock) around the generation check and the rename.
+type fileStore struct {
+	path string
+}
+
+// NewFileStore creates a file-backed store at path (created on first Save).
+func NewFileStore(path string) Store {
+	return &fileStore{path: path}
+}
+
+func (s *fileStore) Load(_ context.Context) ([]Record, int64, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, 0, err
+	}
+	return doc.Keys, doc.Generation, nil
+}
+
+func (s *fileStore) Save(ctx context.Context, gen int64, records []Record) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := s.read()
+	if err != nil {
+		return err
+	}
+	if current.Generation != gen {
+		return ErrConflict
+	}
+
+	raw, err := json.MarshalIndent(fileDocument{
+		Version:    fileFormatVersion,
+		Generation: gen + 1,
+		Keys:       records,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("keyring: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("keyring: %w", err)
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("keyring: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("keyring: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("keyring: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("keyring: %w", err)
+	}
+
+	// Persist the rename itself (best effort: not supported everywhere).
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
+
+// read parses the keyring file (an empty document if it does not exist).
+func (s *fileStore) read() (fileDocument, error) {
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fileDocument{}, nil
+	}
+	if err != nil {
+		return fileDocument{}, fmt.Errorf("keyring: read %s: %w", s.path, err)
+	}
+
+	var doc fileDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fileDocument{}, fmt.Errorf("keyring: parse %s: %w", s.path, err)
+	}
+	if doc.Version != fileFormatVersion {
+		return fileDocument{}, fmt.Errorf("keyring: unsupported file version %d", doc.Version)
+	}
+	return doc, nil
+}
+
+// lock creates the lock file exclusively, waiting while another
+// writer holds it. A lock older than staleLockAge is broken.
+func (s *fileStore) lock(ctx context.Context) (unlock func(), err error) {
+	path := s.path + ".lock"
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("keyring: %w", err)
+		}
+
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > staleLockAge {
+			_ = os.Remove(path)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("keyring: waiting for %s: %w", path, ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,618 @@
+// Package keyring persists token signing keys across restarts.
+//
+// Keys are envelope-encrypted: each key's material is encrypted with
+// its own data key (AES-256-GCM), and data keys are wrapped by a KMS
+// key that never leaves the KMS (see package kms). A leaked keyring
+// file or table is useless without access to the KMS.
+//
+// Keys move through states (see State) with timestamps, so rotation
+// can be scheduled: the next key is published before it signs, and
+// old keys are retired once no token they signed can still be valid.
+package keyring
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/kms"
+	"github.com/kararnab/authdemo/pkg/log"
+)
+
+var (
+	ErrNoPendingKey = errors.New("keyring: no pending key")
+	ErrPendingKey   = errors.New("keyring: a pending key already exists")
+	ErrKeyNotFound  = errors.New("keyring: key not found")
+	ErrActiveKey    = errors.New("keyring: the active key cannot be retired")
+)
+
+// Options configures Open.
+type Options struct {
+	Store Store
+
+	// KMS and KMSKeyID wrap the data keys. The key must stay the
+	// same for the life of the keyring.
+	KMS      kms.KMS
+	KMSKeyID string
+
+	// Initial creates the first key of an empty keyring.
+	Initial func() (keys.Key, error)
+
+	// KeyIDPrefix starts the IDs of keys created by NewKey
+	// (default "key").
+	KeyIDPrefix string
+
+	// ReloadInterval is how often the keyring is reloaded from the
+	// store, so changes made by other processes sharing it (rotation,
+	// retirement) are picked up (default 1m).
+	ReloadInterval time.Duration
+
+	// MinReloadInterval rate-limits reloads triggered by unknown kids,
+	// so tokens with random kids cannot hammer the store (default 5s).
+	MinReloadInterval time.Duration
+}
+
+// KeyInfo is the metadata of a key (never its material).
+type KeyInfo struct {
+	ID            string
+	Algorithm     keys.Algorithm
+	State         State
+	CreatedAt     time.Time
+	ActivatedAt   time.Time // zero while pending
+	DeactivatedAt time.Time // zero while pending / active
+	RetiredAt     time.Time // zero unless retired
+}
+
+// Keyring is a persistent keys.Provider with key lifecycle states.
+//
+// Reads are served from memory. Every change reloads the keyring,
+// applies to the latest stored records and saves them (compare-and-swap,
+// retried on conflict) before updating memory, so a failed write
+// leaves both unchanged and processes sharing a store never drop each
+// other's keys.
+//
+// Provider view:
+//   - ActiveKey: the active key
+//   - VerificationKeys / KeyByID: active, pending and verify-only keys
+//     (pending keys are pre-published, e.g. in the JWKS)
+//
+// Reads reload the keyring every ReloadInterval, and an unknown kid
+// forces a reload (at most once per MinReloadInterval). Reloads run
+// inline on the calling request, like JWKS refreshes.
+type Keyring struct {
+	store    Store
+	kms      kms.KMS
+	kmsKeyID string
+	opts     Options
+
+	writeMu sync.Mutex // serializes changes and reloads
+
+	mu         sync.RWMutex
+	records    []Record            // newest first (as stored)
+	keys       map[string]keys.Key // decrypted keys, retired excluded
+	gen        int64               // store generation of records
+	lastReload time.Time           // last reload attempt (rate limit)
+}
+
+// maxSaveAttempts bounds the retries of a change that keeps
+// conflicting with writes of other processes.
+const maxSaveAttempts = 5
+
+// reloadTimeout bounds reloads run on behalf of key lookups.
+const reloadTimeout = 5 * time.Second
+
+// Open loads the keyring, creating it with opts.Initial when empty.
+//
+// Fails if any stored key cannot be decrypted (e.g. wrong KMS key):
+// silently dropping keys would invalidate live tokens.
+func Open(ctx context.Context, opts Options) (*Keyring, error) {
+	if opts.Store == nil {
+		return nil, errors.New("keyring: store is required")
+	}
+	if opts.KMS == nil || opts.KMSKeyID == "" {
+		return nil, errors.New("keyring: KMS and KMS key ID are required")
+	}
+
+	if opts.KeyIDPrefix == "" {
+		opts.KeyIDPrefix = "key"
+	}
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = time.Minute
+	}
+	if opts.MinReloadInterval <= 0 {
+		opts.MinReloadInterval = 5 * time.Second
+	}
+
+	kr := &Keyring{
+		store:    opts.Store,
+		kms:      opts.KMS,
+		kmsKeyID: opts.KMSKeyID,
+		opts:     opts,
+	}
+
+	kr.writeMu.Lock()
+	defer kr.writeMu.Unlock()
+
+	// Another process may create the keyring concurrently: the
+	// conflict retry then loads its initial key instead.
+	err := kr.update(ctx, func(records []Record) ([]Record, error) {
+		if len(records) > 0 {
+			return nil, nil
+		}
+		if opts.Initial == nil {
+			return nil, errors.New("keyring: empty keyring and no initial key")
+		}
+		initial, err := opts.Initial()
+		if err != nil {
+			return nil, err
+		}
+		rec, err := kr.seal(ctx, initial, StateActive, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return []Record{rec}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// Reload loads changes made by other processes sharing the store.
+func (kr *Keyring) Reload(ctx context.Context) error {
+	kr.writeMu.Lock()
+	defer kr.writeMu.Unlock()
+
+	return kr.update(ctx, nil)
+}
+
+// withStates fills in states of records written before keys had
+// states: the first key is active, older ones stopped signing when
+// the next newer key was created.
+func withStates(records []Record) []Record {
+	out := slices.Clone(records)
+	for i := range out {
+		if out[i].State != "" {
+			continue
+		}
+		out[i].ActivatedAt = out[i].CreatedAt
+		if i == 0 {
+			out[i].State = StateActive
+			continue
+		}
+		out[i].State = StateVerifyOnly
+		out[i].DeactivatedAt = out[i-1].CreatedAt
+	}
+	return out
+}
+
+// ActiveKey implements keys.Provider.
+func (kr *Keyring) ActiveKey() keys.Key {
+	kr.reloadAfter(kr.opts.ReloadInterval)
+
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	for _, rec := range kr.records {
+		if rec.State == StateActive {
+			return kr.keys[rec.ID]
+		}
+	}
+	return keys.Key{}
+}
+
+// VerificationKeys implements keys.Provider.
+//
+// Order matters:
+//   - active key first (fast path)
+//   - then pending and verify-only keys, newest first
+func (kr *Keyring) VerificationKeys() []keys.Key {
+	kr.reloadAfter(kr.opts.ReloadInterval)
+
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	out := make([]keys.Key, 0, len(kr.keys))
+	for _, rec := range kr.records {
+		if rec.State == StateActive {
+			out = append(out, kr.keys[rec.ID])
+		}
+	}
+	for _, rec := range kr.records {
+		if rec.State == StatePending || rec.State == StateVerifyOnly {
+			out = append(out, kr.keys[rec.ID])
+		}
+	}
+	return out
+}
+
+// KeyByID implements keys.Provider (retired keys are not found).
+//
+// An unknown kid forces a reload: the key may have been added by
+// another process sharing the store.
+func (kr *Keyring) KeyByID(id string) (keys.Key, bool) {
+	kr.reloadAfter(kr.opts.ReloadInterval)
+
+	if k, ok := kr.lookup(id); ok {
+		return k, true
+	}
+
+	kr.reloadAfter(kr.opts.MinReloadInterval)
+	return kr.lookup(id)
+}
+
+func (kr *Keyring) lookup(id string) (keys.Key, bool) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	k, ok := kr.keys[id]
+	return k, ok
+}
+
+// Keys returns the metadata of all keys, retired ones included,
+// newest first.
+func (kr *Keyring) Keys() []KeyInfo {
+	kr.reloadAfter(kr.opts.ReloadInterval)
+
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	out := make([]KeyInfo, 0, len(kr.records))
+	for _, rec := range kr.records {
+		out = append(out, KeyInfo{
+			ID:            rec.ID,
+			Algorithm:     rec.Algorithm,
+			State:         rec.State,
+			CreatedAt:     rec.CreatedAt,
+			ActivatedAt:   rec.ActivatedAt,
+			DeactivatedAt: rec.DeactivatedAt,
+			RetiredAt:     rec.RetiredAt,
+		})
+	}
+	return out
+}
+
+// NewKey generates (without adding) a key for the next rotation: same
+// algorithm as the active key, unique time-ordered ID.
+func (kr *Keyring) NewKey() (keys.Key, error) {
+	return keys.Generate(keys.NewID(kr.opts.KeyIDPrefix), kr.ActiveKey().Alg())
+}
+
+// Stage adds newKey as the pending key: published for verification,
+// but not signing until Promote.
+//
+// Only one key may be pending at a time.
+func (kr *Keyring) Stage(ctx context.Context, newKey keys.Key) error {
+	rec, err := kr.seal(ctx, newKey, StatePending, time.Now())
+	if err != nil {
+		return err
+	}
+
+	kr.writeMu.Lock()
+	defer kr.writeMu.Unlock()
+
+	return kr.update(ctx, func(records []Record) ([]Record, error) {
+		if err := checkNew(records, newKey); err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(records, func(rec Record) bool {
+			return rec.State == StatePending
+		}) {
+			return nil, ErrPendingKey
+		}
+		return append([]Record{rec}, records...), nil
+	}, newKey)
+}
+
+// Promote makes the pending key active; the previously active key
+// becomes verify-only.
+func (kr *Keyring) Promote(ctx context.Context) error {
+	kr.writeMu.Lock()
+	defer kr.writeMu.Unlock()
+
+	return kr.update(ctx, func(records []Record) ([]Record, error) {
+		pending := slices.IndexFunc(records, func(rec Record) bool {
+			return rec.State == StatePending
+		})
+		if pending < 0 {
+			return nil, ErrNoPendingKey
+		}
+
+		now := time.Now()
+		demote(records, now)
+		records[pending].State = StateActive
+		records[pending].ActivatedAt = now
+		return records, nil
+	})
+}
+
+// Rotate makes newKey active immediately (without a pending phase);
+// the previous active key becomes verify-only.
+//
+// A pending key, if any, is retired: it never signed, and promoting
+// it later would bring back a key generated before the rotation
+// (e.g. one staged before a key compromise, or with an old algorithm).
+func (kr *Keyring) Rotate(ctx context.Context, newKey keys.Key) error {
+	now := time.Now()
+	rec, err := kr.seal(ctx, newKey, StateActive, now)
+	if err != nil {
+		return err
+	}
+
+	kr.writeMu.Lock()
+	defer kr.writeMu.Unlock()
+
+	return kr.update(ctx, func(records []Record) ([]Record, error) {
+		if err := checkNew(records, newKey); err != nil {
+			return nil, err
+		}
+		for i := range records {
+			if records[i].State == StatePending {
+				retire(&records[i], now)
+			}
+		}
+		demote(records, now)
+		return append([]Record{rec}, records...), nil
+	}, newKey)
+}
+
+// RetireExpired retires verify-only keys that stopped signing at
+// least maxTokenTTL before now: every token they signed has expired.
+//
+// maxTokenTTL must cover the longest-lived token signed with the
+// keyring (plus clock skew). Returns the IDs of retired keys.
+func (kr *Keyring) RetireExpired(
+	ctx context.Context,
+	now time.Time,
+	maxTokenTTL time.Duration,
+) ([]string, error) {
+
+	kr.writeMu.Lock()
+	defer kr.writeMu.Unlock()
+
+	var retired []string
+	err := kr.update(ctx, func(records []Record) ([]Record, error) {
+		retired = nil // per attempt
+		for i := range records {
+			rec := &records[i]
+			if rec.State != StateVerifyOnly || now.Before(rec.DeactivatedAt.Add(maxTokenTTL)) {
+				continue
+			}
+			retire(rec, now)
+			retired = append(retired, rec.ID)
+		}
+		if len(retired) == 0 {
+			return nil, nil
+		}
+		return records, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return retired, nil
+}
+
+// Retire retires a pending or verify-only key now: tokens it signed
+// are rejected from then on (e.g. after a key compromise).
+//
+// Retiring a retired key is a no-op; the active key must be rotated
+// out first (ErrActiveKey).
+func (kr *Keyring) Retire(ctx context.Context, id string) error {
+	kr.writeMu.Lock()
+	defer kr.writeMu.Unlock()
+
+	return kr.update(ctx, func(records []Record) ([]Record, error) {
+		i := slices.IndexFunc(records, func(rec Record) bool {
+			return rec.ID == id
+		})
+		switch {
+		case i < 0:
+			return nil, ErrKeyNotFound
+		case records[i].State == StateRetired:
+			return nil, nil
+		case records[i].State == StateActive:
+			return nil, ErrActiveKey
+		}
+
+		retire(&records[i], time.Now())
+		return records, nil
+	})
+}
+
+// checkNew rejects key IDs already used in the keyring. Retired IDs
+// count: a kid must never be reused.
+func checkNew(records []Record, k keys.Key) error {
+	if k.ID == "" {
+		return errors.New("keyring: key id is required")
+	}
+	for _, rec := range records {
+		if rec.ID == k.ID {
+			return errors.New("keyring: duplicate key id " + k.ID)
+		}
+	}
+	return nil
+}
+
+// update loads the latest stored records, applies change to them and
+// saves the result, retrying on ErrConflict; memory is then replaced
+// with what was saved (callers hold writeMu).
+//
+// change gets a copy it may modify and is called again on retries. It
+// returns nil records for no change, and a nil change only reloads.
+// added are the decrypted keys of the records change adds.
+func (kr *Keyring) update(
+	ctx context.Context,
+	change func(records []Record) ([]Record, error),
+	added ...keys.Key,
+) error {
+
+	for attempt := 1; ; attempt++ {
+		kr.mu.Lock()
+		kr.lastReload = time.Now()
+		kr.mu.Unlock()
+
+		records, gen, err := kr.store.Load(ctx)
+		if err != nil {
+			return err
+		}
+		records = withStates(records)
+
+		var next []Record
+		if change != nil {
+			if next, err = change(slices.Clone(records)); err != nil {
+				return err
+			}
+		}
+
+		if next == nil {
+			if kr.keys != nil && gen == kr.gen {
+				return nil // unchanged since the last load
+			}
+			loaded, err := kr.decrypt(ctx, records, nil)
+			if err != nil {
+				return err
+			}
+			kr.set(records, loaded, gen)
+			return nil
+		}
+
+		// Decrypt before saving: an unusable keyring is never written.
+		loaded, err := kr.decrypt(ctx, next, added)
+		if err != nil {
+			return err
+		}
+
+		err = kr.store.Save(ctx, gen, next)
+		if errors.Is(err, ErrConflict) && attempt < maxSaveAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		kr.set(next, loaded, gen+1)
+		return nil
+	}
+}
+
+// decrypt returns the usable (non-retired) keys of records, reusing
+// keys already in memory or in added (callers hold writeMu).
+//
+// Fails unless exactly one key is active.
+func (kr *Keyring) decrypt(
+	ctx context.Context,
+	records []Record,
+	added []keys.Key,
+) (map[string]keys.Key, error) {
+
+	active := 0
+	loaded := make(map[string]keys.Key, len(records))
+	for _, rec := range records {
+		switch rec.State {
+		case StateRetired:
+			continue
+		case StateActive:
+			active++
+		}
+
+		if k, ok := kr.keys[rec.ID]; ok {
+			loaded[rec.ID] = k
+			continue
+		}
+		if i := slices.IndexFunc(added, func(k keys.Key) bool { return k.ID == rec.ID }); i >= 0 {
+			loaded[rec.ID] = added[i]
+			continue
+		}
+
+		k, err := open(ctx, kr.kms, kr.kmsKeyID, rec)
+		if err != nil {
+			return nil, err
+		}
+		loaded[rec.ID] = k
+	}
+	if active != 1 {
+		return nil, errors.New("keyring: expected exactly one active key")
+	}
+	return loaded, nil
+}
+
+func (kr *Keyring) set(records []Record, loaded map[string]keys.Key, gen int64) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	kr.records = records
+	kr.keys = loaded
+	kr.gen = gen
+}
+
+// reloadAfter reloads the keyring when the last reload is at least d
+// old. Skipped while a change or another reload is in flight: it
+// loads the store anyway, and lookups should not queue behind it.
+func (kr *Keyring) reloadAfter(d time.Duration) {
+	kr.mu.RLock()
+	due := time.Since(kr.lastReload) >= d
+	kr.mu.RUnlock()
+
+	if !due || !kr.writeMu.TryLock() {
+		return
+	}
+	defer kr.writeMu.Unlock()
+
+	// Re-check: a reload may have finished since.
+	kr.mu.RLock()
+	due = time.Since(kr.lastReload) >= d
+	kr.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+	defer cancel()
+
+	if err := kr.update(ctx, nil); err != nil {
+		log.Warn(
+			"signing keyring reload failed",
+			log.F("error", err, log.RedactNone),
+		)
+	}
+}
+
+// seal encrypts a key for storage.
+func (kr *Keyring) seal(
+	ctx context.Context,
+	k keys.Key,
+	state State,
+	now time.Time,
+) (Record, error) {
+
+	rec, err := seal(ctx, kr.kms, kr.kmsKeyID, k)
+	if err != nil {
+		return Record{}, err
+	}
+	rec.State = state
+	rec.CreatedAt = now
+	if state == StateActive {
+		rec.ActivatedAt = now
+	}
+	return rec, nil
+}
+
+// demote turns the active key verify-only.
+func demote(records []Record, now time.Time) {
+	for i := range records {
+		if records[i].State == StateActive {
+			records[i].State = StateVerifyOnly
+			records[i].DeactivatedAt = now
+		}
+	}
+}
+
+// retire marks a key retired and destroys its (encrypted) material.
+func retire(rec *Record, now time.Time) {
+	rec.State = StateRetired
+	rec.RetiredAt = now
+	rec.WrappedKey = nil
+	rec.Ciphertext = nil
+}
@@ -0,0 +1,121 @@
+package keyring_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys/keyring"
+	"github.com/kararnab/authdemo/pkg/kms"
+)
+
+func TestRotateRetiresPendingKey(t *testing.T) {
+	ctx := context.Background()
+	kr := openTestKeyring(t, keyring.NewMemoryStore(), keys.ES256)
+
+	staged := newTestKey(t, "staged", keys.ES256)
+	if err := kr.Stage(ctx, staged); err != nil {
+		t.Fatalf("Stage: %v", err)
+	}
+
+	// e.g. an emergency rotation, or a new signing algorithm at startup
+	rotated := newTestKey(t, "rotated", keys.EdDSA)
+	if err := kr.Rotate(ctx, rotated); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	if err := kr.Promote(ctx); !errors.Is(err, keyring.ErrNoPendingKey) {
+		t.Fatalf("Promote after Rotate: err = %v, want ErrNoPendingKey", err)
+	}
+	if got := kr.ActiveKey().ID; got != "rotated" {
+		t.Fatalf("active key = %q, want the rotated key", got)
+	}
+	if _, ok := kr.KeyByID("staged"); ok {
+		t.Fatal("the key staged before the rotation is still accepted")
+	}
+	if got := stateOf(kr, "staged"); got != keyring.StateRetired {
+		t.Fatalf("staged key state = %q, want retired", got)
+	}
+}
+
+func TestSchedulerDoesNotPromoteKeyStagedBeforeRotate(t *testing.T) {
+	ctx := context.Background()
+	kr := openTestKeyring(t, keyring.NewMemoryStore(), keys.ES256)
+
+	s, err := keyring.NewScheduler(kr, keyring.SchedulerOptions{
+		RotationInterval: time.Hour,
+		PrePublish:       10 * time.Minute,
+		MaxTokenTTL:      time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+
+	// Stage the next key of the old algorithm...
+	if err := s.Check(ctx, time.Now().Add(55*time.Minute)); err != nil {
+		t.Fatalf("Check (stage): %v", err)
+	}
+	// ...then rotate to a new algorithm before it is promoted.
+	if err := kr.Rotate(ctx, newTestKey(t, "rotated", keys.EdDSA)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// Past the old due time: nothing stale to promote.
+	if err := s.Check(ctx, time.Now().Add(65*time.Minute)); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got := kr.ActiveKey(); got.ID != "rotated" || got.Alg() != keys.EdDSA {
+		t.Fatalf("active key = %q (%s), want the rotated EdDSA key", got.ID, got.Alg())
+	}
+}
+
+func openTestKeyring(t *testing.T, store keyring.Store, alg keys.Algorithm) *keyring.Keyring {
+	t.Helper()
+
+	kr, err := keyring.Open(context.Background(), keyring.Options{
+		Store:    store,
+		KMS:      testKMS(t),
+		KMSKeyID: "test",
+		Initial: func() (keys.Key, error) {
+			return keys.Generate(keys.NewID("initial"), alg)
+		},
+		MinReloadInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return kr
+}
+
+// testKMS wraps with a fixed key, so keyrings opened on the same store
+// can decrypt each other's keys.
+func testKMS(t *testing.T) kms.KMS {
+	t.Helper()
+
+	k, err := kms.NewStatic("test", make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewStatic: %v", err)
+	}
+	return k
+}
+
+func newTestKey(t *testing.T, id string, alg keys.Algorithm) keys.Key {
+	t.Helper()
+
+	k, err := keys.Generate(id, alg)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return k
+}
+
+func stateOf(kr *keyring.Keyring, id string) keyring.State {
+	for _, info := range kr.Keys() {
+		if info.ID == id {
+			return info.State
+		}
+	}
+	return ""
+}
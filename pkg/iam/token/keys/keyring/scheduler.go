@@ -0,0 +1,214 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/log"
+)
+
+// SchedulerOptions configures automatic rotation.
+type SchedulerOptions struct {
+	// RotationInterval is how long a key signs before the next one
+	// is promoted (required).
+	RotationInterval time.Duration
+
+	// PrePublish is how long the next key is published (pending)
+	// before it signs. It must exceed the JWKS cache lifetime of
+	// verifiers (default 1h, at most RotationInterval).
+	PrePublish time.Duration
+
+	// MaxTokenTTL is the lifetime of the longest-lived token signed
+	// with the keyring, plus clock skew (required). Keys are retired
+	// this long after they stopped signing.
+	MaxTokenTTL time.Duration
+
+	CheckInterval time.Duration // default 1m
+}
+
+// Scheduler rotates a keyring on a cadence.
+//
+// Each check:
+//   - stages the next key (Keyring.NewKey) PrePublish before rotation
+//     is due
+//   - promotes it once due (and published for PrePublish)
+//   - retires verify-only keys older than MaxTokenTTL
+//
+// Checks work from the stored timestamps, so a restart does not
+// reset the cadence. Replicas sharing a keyring store may each run a
+// scheduler: checks start from the reloaded keyring, and a step
+// another replica took first is skipped.
+//
+// Lifecycle:
+//   - Start launches the background loop (once)
+//   - Close stops it and waits for an in-flight check
+type Scheduler struct {
+	kr   *Keyring
+	opts SchedulerOptions
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewScheduler creates a scheduler for kr.
+func NewScheduler(kr *Keyring, opts SchedulerOptions) (*Scheduler, error) {
+	if opts.RotationInterval <= 0 {
+		return nil, errors.New("keyring: rotation interval is required")
+	}
+	if opts.MaxTokenTTL <= 0 {
+		return nil, errors.New("keyring: max token TTL is required")
+	}
+	if opts.PrePublish <= 0 {
+		opts.PrePublish = time.Hour
+	}
+	opts.PrePublish = min(opts.PrePublish, opts.RotationInterval)
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = time.Minute
+	}
+
+	return &Scheduler{
+		kr:   kr,
+		opts: opts,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// Start runs the check loop until ctx is cancelled or Close is called.
+//
+// The first check runs immediately (e.g. to retire keys that expired
+// while the server was down).
+func (s *Scheduler) Start(ctx context.Context) {
+	s.startOnce.Do(func() {
+		go s.run(ctx)
+	})
+}
+
+// Close stops the loop and waits for it to exit.
+//
+// Returns ctx.Err() if ctx ends first.
+func (s *Scheduler) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	// Never started: nothing to wait for.
+	started := true
+	s.startOnce.Do(func() {
+		started = false
+		close(s.done)
+	})
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check runs one pass of the schedule at now.
+func (s *Scheduler) Check(ctx context.Context, now time.Time) error {
+	if err := s.kr.Reload(ctx); err != nil {
+		return err
+	}
+
+	var active, pending *KeyInfo
+	infos := s.kr.Keys()
+	for i := range infos {
+		switch infos[i].State {
+		case StateActive:
+			active = &infos[i]
+		case StatePending:
+			pending = &infos[i]
+		}
+	}
+	if active == nil {
+		return errors.New("keyring: no active key")
+	}
+
+	due := active.ActivatedAt.Add(s.opts.RotationInterval)
+
+	if pending == nil && !now.Before(due.Add(-s.opts.PrePublish)) {
+		next, err := s.kr.NewKey()
+		if err != nil {
+			return err
+		}
+		switch err := s.kr.Stage(ctx, next); {
+		case errors.Is(err, ErrPendingKey):
+			// staged by another replica
+		case err != nil:
+			return err
+		default:
+			log.Info(
+				"next signing key published",
+				log.F("kid", next.ID, log.RedactNone),
+			)
+		}
+		// promoted on a later check, after PrePublish
+	} else if pending != nil && !now.Before(due) && !now.Before(pending.CreatedAt.Add(s.opts.PrePublish)) {
+		switch err := s.kr.Promote(ctx); {
+		case errors.Is(err, ErrNoPendingKey):
+			// promoted by another replica
+		case err != nil:
+			return err
+		default:
+			log.Info(
+				"signing key rotated",
+				log.F("kid", pending.ID, log.RedactNone),
+				log.F("previous_kid", active.ID, log.RedactNone),
+			)
+		}
+	}
+
+	retired, err := s.kr.RetireExpired(ctx, now, s.opts.MaxTokenTTL)
+	if err != nil {
+		return err
+	}
+	for _, id := range retired {
+		log.Info(
+			"signing key retired",
+			log.F("kid", id, log.RedactNone),
+		)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	// Stop in-flight checks on Close as well.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(s.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Check(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Warn(
+				"key rotation check failed",
+				log.F("error", err, log.RedactNone),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
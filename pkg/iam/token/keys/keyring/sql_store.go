@@ -0,0 +1,292 @@
+package keyring
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+)
+
+// Dialect selects SQL flavour differences (placeholders).
+//
+// The schema sticks to portable types, so the same migration runs
+// on SQLite and Postgres.
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// sqlStore is a database/sql implementation of Store.
+//
+// Several keyrings (one per token format) share the signing_keys
+// table, told apart by name. Their generations are kept in
+// signing_keyrings; a Save bumps it with a conditional UPDATE in the
+// same transaction that rewrites the keys. Encrypted fields are stored base64-encoded
+// and times as unix nanoseconds, 0 for unset (portable types only).
+type sqlStore struct {
+	db      *sql.DB
+	dialect Dialect
+	name    string
+}
+
+// NewSQLStore creates a persistent store for the keyring called name.
+//
+// The schema must exist: call MigrateSQL first.
+func NewSQLStore(db *sql.DB, dialect Dialect, name string) Store {
+	return &sqlStore{
+		db:      db,
+		dialect: dialect,
+		name:    name,
+	}
+}
+
+// lifecycleColumns were added with key states; tables created
+// before get them through ALTER TABLE.
+var lifecycleColumns = []string{
+	"state TEXT NOT NULL DEFAULT ''",
+	"activated_at BIGINT NOT NULL DEFAULT 0",
+	"deactivated_at BIGINT NOT NULL DEFAULT 0",
+	"retired_at BIGINT NOT NULL DEFAULT 0",
+}
+
+// MigrateSQL creates (or upgrades) the signing_keys and
+// signing_keyrings tables. Safe to call on every start.
+func MigrateSQL(
+	ctx context.Context,
+	db *sql.DB,
+) error {
+
+	// No row: generation 0 (keyrings saved before generations).
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS signing_keyrings (
+		keyring    TEXT PRIMARY KEY,
+		generation BIGINT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("keyring: migrate: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS signing_keys (
+		keyring        TEXT NOT NULL,
+		position       INTEGER NOT NULL,
+		id             TEXT NOT NULL,
+		algorithm      TEXT NOT NULL,
+		created_at     BIGINT NOT NULL,
+		wrapped_key    TEXT NOT NULL,
+		ciphertext     TEXT NOT NULL,
+		state          TEXT NOT NULL DEFAULT '',
+		activated_at   BIGINT NOT NULL DEFAULT 0,
+		deactivated_at BIGINT NOT NULL DEFAULT 0,
+		retired_at     BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (keyring, id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("keyring: migrate: %w", err)
+	}
+
+	// Empty state: records written before states, filled in on Open.
+	if _, err := db.ExecContext(ctx, `SELECT state FROM signing_keys WHERE 1 = 0`); err == nil {
+		return nil
+	}
+	for _, column := range lifecycleColumns {
+		if _, err := db.ExecContext(ctx, `ALTER TABLE signing_keys ADD COLUMN `+column); err != nil {
+			return fmt.Errorf("keyring: migrate: %w", err)
+		}
+	}
+	return nil
+}
+
+// Load reads the generation and the keys in one transaction.
+func (s *sqlStore) Load(ctx context.Context) ([]Record, int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	gen, err := s.generation(ctx, tx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, s.rebind(
+		`SELECT id, algorithm, state, created_at, activated_at, deactivated_at,
+		retired_at, wrapped_key, ciphertext
+		FROM signing_keys WHERE keyring = ? ORDER BY position`),
+		s.name,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var (
+			rec                                  Record
+			alg, state                           string
+			created, activated, deactivated, ret int64
+			wrapped, ciphertext                  string
+		)
+		if err := rows.Scan(
+			&rec.ID, &alg, &state, &created, &activated, &deactivated,
+			&ret, &wrapped, &ciphertext,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		rec.Algorithm = keys.Algorithm(alg)
+		rec.State = State(state)
+		rec.CreatedAt = fromUnixNano(created)
+		rec.ActivatedAt = fromUnixNano(activated)
+		rec.DeactivatedAt = fromUnixNano(deactivated)
+		rec.RetiredAt = fromUnixNano(ret)
+		if rec.WrappedKey, err = base64.StdEncoding.DecodeString(wrapped); err != nil {
+			return nil, 0, fmt.Errorf("keyring: corrupt key %q", rec.ID)
+		}
+		if rec.Ciphertext, err = base64.StdEncoding.DecodeString(ciphertext); err != nil {
+			return nil, 0, fmt.Errorf("keyring: corrupt key %q", rec.ID)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return records, gen, nil
+}
+
+// Save replaces the keyring in one transaction, if the generation
+// is still gen.
+func (s *sqlStore) Save(ctx context.Context, gen int64, records []Record) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = s.bump(ctx, tx, gen); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, s.rebind(
+		`DELETE FROM signing_keys WHERE keyring = ?`), s.name,
+	); err != nil {
+		return err
+	}
+
+	for i, rec := range records {
+		if _, err = tx.ExecContext(ctx, s.rebind(
+			`INSERT INTO signing_keys
+			(keyring, position, id, algorithm, state, created_at, activated_at,
+			deactivated_at, retired_at, wrapped_key, ciphertext)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			s.name, i, rec.ID, string(rec.Algorithm), string(rec.State),
+			unixNano(rec.CreatedAt), unixNano(rec.ActivatedAt),
+			unixNano(rec.DeactivatedAt), unixNano(rec.RetiredAt),
+			base64.StdEncoding.EncodeToString(rec.WrappedKey),
+			base64.StdEncoding.EncodeToString(rec.Ciphertext),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// generation reads the keyring generation (0 without a row).
+func (s *sqlStore) generation(ctx context.Context, tx *sql.Tx) (int64, error) {
+	var gen int64
+	err := tx.QueryRowContext(ctx, s.rebind(
+		`SELECT generation FROM signing_keyrings WHERE keyring = ?`), s.name,
+	).Scan(&gen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return gen, err
+}
+
+// bump moves the generation from gen to gen+1, or fails with
+// ErrConflict. The UPDATE locks the row until the transaction ends,
+// so concurrent saves from the same generation cannot both succeed.
+func (s *sqlStore) bump(ctx context.Context, tx *sql.Tx, gen int64) error {
+	res, err := tx.ExecContext(ctx, s.rebind(
+		`UPDATE signing_keyrings SET generation = ? WHERE keyring = ? AND generation = ?`),
+		gen+1, s.name, gen,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 1 {
+		return nil
+	}
+
+	// First save of this keyring: create the row, unless a concurrent
+	// first save did.
+	if gen != 0 {
+		return ErrConflict
+	}
+	res, err = tx.ExecContext(ctx, s.rebind(
+		`INSERT INTO signing_keyrings (keyring, generation) VALUES (?, 1)
+		ON CONFLICT (keyring) DO NOTHING`), s.name,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err = res.RowsAffected(); err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// unixNano stores a time, 0 for the zero time.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano is the inverse of unixNano.
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// rebind converts "?" placeholders to "$n" for Postgres.
+func (s *sqlStore) rebind(query string) string {
+	if s.dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+)
+
+// State is the lifecycle state of a key.
+//
+//	pending → active → verify-only → retired
+type State string
+
+const (
+	// StatePending keys are published for verification (JWKS) but do
+	// not sign yet, so verifiers caching the key set learn them before
+	// the first token signed with them arrives.
+	StatePending State = "pending"
+
+	// StateActive is the one key that signs new tokens.
+	StateActive State = "active"
+
+	// StateVerifyOnly keys stopped signing; tokens they signed are
+	// still accepted until they expire.
+	StateVerifyOnly State = "verify-only"
+
+	// StateRetired keys are no longer accepted. Their material is
+	// destroyed; only the metadata is kept.
+	StateRetired State = "retired"
+)
+
+// Record is a persisted, envelope-encrypted key.
+//
+// Only the key ID, algorithm, state and timestamps are stored in clear.
+type Record struct {
+	ID        string         `json:"id"`
+	Algorithm keys.Algorithm `json:"alg"`
+	State     State          `json:"state"`
+
+	CreatedAt     time.Time `json:"created_at"`              // staged (or created active)
+	ActivatedAt   time.Time `json:"activated_at,omitzero"`   // started signing
+	DeactivatedAt time.Time `json:"deactivated_at,omitzero"` // stopped signing
+	RetiredAt     time.Time `json:"retired_at,omitzero"`     // stopped verifying
+
+	WrappedKey []byte `json:"wrapped_key,omitempty"` // data key, encrypted by the KMS
+	Ciphertext []byte `json:"ciphertext,omitempty"`  // key material, encrypted with the data key
+}
+
+// ErrConflict is returned by Store.Save when the keyring was changed
+// since it was loaded (by another process sharing the store).
+var ErrConflict = errors.New("keyring: changed concurrently")
+
+// Store persists a keyring.
+//
+// The keyring is small and changes rarely, so it is always written as
+// a whole: Save MUST replace the stored records atomically (readers
+// never see a half-rotated keyring).
+//
+// Several processes may share a store (replicas, or a restarted
+// process overlapping the old one). Saves are compare-and-swap on a
+// generation counter bumped by every save, so a process never
+// overwrites changes it has not loaded.
+type Store interface {
+	// Load returns the records, newest first (empty if none), and the
+	// generation they were saved at (0 if never saved).
+	Load(ctx context.Context) ([]Record, int64, error)
+
+	// Save replaces all records (newest first) if the stored
+	// generation is still gen, and bumps it; ErrConflict otherwise.
+	Save(ctx context.Context, gen int64, records []Record) error
+}
+
+// memoryStore keeps records in process memory (lost on restart).
+type memoryStore struct {
+	mu      sync.Mutex
+	gen     int64
+	records []Record
+}
+
+// NewMemoryStore creates a non-persistent store (tests, single-run demos).
+func NewMemoryStore() Store {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) Load(_ context.Context) ([]Record, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Record(nil), s.records...), s.gen, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, gen int64, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if gen != s.gen {
+		return ErrConflict
+	}
+	s.records = append([]Record(nil), records...)
+	s.gen++
+	return nil
+}
@@ -0,0 +1,186 @@
+package keyring_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys/keyring"
+)
+
+// sharedStores returns, per store kind, a function opening another
+// handle on the same underlying storage (as a second process would).
+func sharedStores(t *testing.T) map[string]func() keyring.Store {
+	t.Helper()
+
+	mem := keyring.NewMemoryStore()
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	db := openTestDB(t)
+
+	return map[string]func() keyring.Store{
+		"Memory": func() keyring.Store { return mem },
+		"File":   func() keyring.Store { return keyring.NewFileStore(path) },
+		"SQL":    func() keyring.Store { return keyring.NewSQLStore(db, keyring.SQLite, "jwt") },
+	}
+}
+
+func TestStoreSaveIsCompareAndSwap(t *testing.T) {
+	ctx := context.Background()
+
+	for name, open := range sharedStores(t) {
+		t.Run(name, func(t *testing.T) {
+			a, b := open(), open()
+
+			records, gen, err := a.Load(ctx)
+			if err != nil || len(records) != 0 || gen != 0 {
+				t.Fatalf("Load (empty) = %d records, gen %d, err %v", len(records), gen, err)
+			}
+
+			if err := a.Save(ctx, 0, []keyring.Record{{ID: "k1", State: keyring.StateActive}}); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			// b loaded the empty keyring too.
+			if err := b.Save(ctx, 0, []keyring.Record{{ID: "k2", State: keyring.StateActive}}); !errors.Is(err, keyring.ErrConflict) {
+				t.Fatalf("Save from a stale generation: err = %v, want ErrConflict", err)
+			}
+
+			records, gen, err = b.Load(ctx)
+			if err != nil || len(records) != 1 || records[0].ID != "k1" || gen != 1 {
+				t.Fatalf("Load = %v, gen %d, err %v; want [k1], gen 1", recordIDs(records), gen, err)
+			}
+			if err := b.Save(ctx, gen, []keyring.Record{{ID: "k2"}, records[0]}); err != nil {
+				t.Fatalf("Save from the current generation: %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyringsSharingStoreKeepEachOthersKeys(t *testing.T) {
+	ctx := context.Background()
+
+	for name, open := range sharedStores(t) {
+		t.Run(name, func(t *testing.T) {
+			a := openTestKeyring(t, open(), keys.ES256)
+			b := openSharedKeyring(t, open())
+			if a.ActiveKey().ID != b.ActiveKey().ID {
+				t.Fatal("the second keyring did not load the first one's key")
+			}
+
+			// a rotates; b has not reloaded since.
+			if err := a.Rotate(ctx, newTestKey(t, "from-a", keys.ES256)); err != nil {
+				t.Fatalf("Rotate: %v", err)
+			}
+			// b's change must apply on top of a's, not overwrite it.
+			if err := b.Stage(ctx, newTestKey(t, "from-b", keys.ES256)); err != nil {
+				t.Fatalf("Stage: %v", err)
+			}
+
+			for _, kr := range []*keyring.Keyring{a, b} {
+				for _, kid := range []string{"from-a", "from-b"} {
+					if _, ok := kr.KeyByID(kid); !ok {
+						t.Fatalf("KeyByID(%q): key of the other keyring not found", kid)
+					}
+				}
+			}
+			if got := b.ActiveKey().ID; got != "from-a" {
+				t.Fatalf("active key = %q, want from-a", got)
+			}
+		})
+	}
+}
+
+func TestKeyringReloadsOnUnknownKeyIDRateLimited(t *testing.T) {
+	ctx := context.Background()
+	store := keyring.NewMemoryStore()
+
+	a := openTestKeyring(t, store, keys.ES256)
+	b, err := keyring.Open(ctx, keyring.Options{
+		Store:             store,
+		KMS:               testKMS(t),
+		KMSKeyID:          "test",
+		ReloadInterval:    time.Hour,
+		MinReloadInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := a.Rotate(ctx, newTestKey(t, "k2", keys.ES256)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	// Within MinReloadInterval of Open: no reload.
+	if _, ok := b.KeyByID("k2"); ok {
+		t.Fatal("KeyByID reloaded within MinReloadInterval")
+	}
+	if err := b.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, ok := b.KeyByID("k2"); !ok {
+		t.Fatal("KeyByID: key not found after Reload")
+	}
+}
+
+func TestFileStoreLoadsFilesWithoutGeneration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	if err := os.WriteFile(path, []byte(`{"version":1,"keys":[{"id":"k1"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := keyring.NewFileStore(path)
+	records, gen, err := store.Load(context.Background())
+	if err != nil || len(records) != 1 || gen != 0 {
+		t.Fatalf("Load = %v, gen %d, err %v; want [k1], gen 0", recordIDs(records), gen, err)
+	}
+	if err := store.Save(context.Background(), 0, records); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+}
+
+// openSharedKeyring opens an existing keyring (no initial key).
+func openSharedKeyring(t *testing.T, store keyring.Store) *keyring.Keyring {
+	t.Helper()
+
+	kr, err := keyring.Open(context.Background(), keyring.Options{
+		Store:             store,
+		KMS:               testKMS(t),
+		KMSKeyID:          "test",
+		MinReloadInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return kr
+}
+
+// openTestDB opens a private, migrated in-memory SQLite database.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// Every connection to ":memory:" is a separate database: keep one.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := keyring.MigrateSQL(context.Background(), db); err != nil {
+		t.Fatalf("MigrateSQL: %v", err)
+	}
+	return db
+}
+
+func recordIDs(records []keyring.Record) []string {
+	ids := make([]string, 0, len(records))
+	for _, rec := range records {
+		ids = append(ids, rec.ID)
+	}
+	return ids
+}
@@ -43,6 +43,22 @@ func (p *MemoryProvider) VerificationKeys() []Key {
 	return keys
 }
 
+// KeyByID returns the verification key with the given ID.
+func (p *MemoryProvider) KeyByID(id string) (Key, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.active.ID == id {
+		return p.active, true
+	}
+	for _, k := range p.old {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
 // Rotate promotes a new key to active.
 //
 // Old active key is retained for verification.
@@ -1,9 +1,47 @@
 package keys
 
+import "crypto"
+
+// Algorithm identifies the signature algorithm a key is used with.
+//
+// Values follow the JOSE "alg" registry (RFC 7518 / RFC 8037) so they
+// can be published as-is in a JWKS document.
+type Algorithm string
+
+const (
+	HS256 Algorithm = "HS256" // HMAC-SHA256, shared secret
+	RS256 Algorithm = "RS256" // RSASSA-PKCS1-v1_5 with SHA-256
+	ES256 Algorithm = "ES256" // ECDSA P-256 with SHA-256
+	EdDSA Algorithm = "EdDSA" // Ed25519
+)
+
 // Key represents a cryptographic key usable for issuing or verifying tokens.
+//
+// Symmetric keys carry their material in Key.
+// Asymmetric keys carry PrivateKey (issuing) and PublicKey (verification);
+// a verify-only key has a nil PrivateKey.
 type Key struct {
-	ID  string // logical key ID (kid)
-	Key []byte // raw key material
+	ID        string    // logical key ID (kid)
+	Algorithm Algorithm // empty means HS256 (legacy symmetric keys)
+	Key       []byte    // raw symmetric key material
+
+	PrivateKey crypto.Signer    // asymmetric signing half (optional)
+	PublicKey  crypto.PublicKey // asymmetric verification half
+}
+
+// Alg returns the key algorithm, defaulting to HS256.
+func (k Key) Alg() Algorithm {
+	if k.Algorithm == "" {
+		return HS256
+	}
+	return k.Algorithm
+}
+
+// IsSymmetric reports whether the key is a shared secret.
+//
+// Symmetric keys MUST never be published.
+func (k Key) IsSymmetric() bool {
+	return k.Alg() == HS256
 }
 
 // Provider exposes active and historical keys.
@@ -12,7 +50,9 @@ type Key struct {
 //   - ActiveKey() is used ONLY for issuing tokens
 //   - VerificationKeys() is used for verification
 //   - VerificationKeys MUST include ActiveKey
+//   - KeyByID() finds a verification key by kid (no scanning by callers)
 type Provider interface {
 	ActiveKey() Key
 	VerificationKeys() []Key
+	KeyByID(id string) (Key, bool)
 }
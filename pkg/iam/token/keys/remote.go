@@ -0,0 +1,358 @@
+package keys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/metrics"
+)
+
+// maxJWKSSize bounds the JWKS document read from the network.
+const maxJWKSSize = 1 << 20
+
+// RemoteOptions configures a RemoteProvider.
+type RemoteOptions struct {
+	// URL of the JWKS document (e.g. https://idp/.well-known/jwks.json).
+	URL string
+
+	// HTTPClient defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+
+	// TTL is how long a fetched key set is used when the response has
+	// no Cache-Control max-age (default 5m). max-age is clamped to
+	// [MinRefreshInterval, MaxTTL] (MaxTTL default 24h).
+	TTL    time.Duration
+	MaxTTL time.Duration
+
+	// MinRefreshInterval rate-limits fetches triggered by unknown kids,
+	// so tokens with random kids cannot hammer the JWKS endpoint
+	// (default 30s).
+	MinRefreshInterval time.Duration
+
+	// MaxStale is how long keys are still served after failed
+	// refreshes (default 24h); after that, verification fails.
+	MaxStale time.Duration
+
+	Metrics metrics.IAMMetrics // optional
+}
+
+// RemoteProvider is a verify-only Provider backed by a remote JWKS
+// document, for services that verify tokens signed elsewhere.
+//
+// Behavior:
+//   - keys are fetched lazily (or by Refresh) and cached per
+//     Cache-Control, revalidated with If-None-Match (ETag)
+//   - an unknown kid triggers a refresh (rate-limited), so keys
+//     rotated by the issuer are picked up without waiting for expiry
+//   - fetch failures keep the last good keys for up to MaxStale
+//
+// ActiveKey returns the zero Key: remote keys never sign.
+type RemoteProvider struct {
+	opts RemoteOptions
+
+	fetchMu sync.Mutex // one fetch at a time
+
+	mu        sync.RWMutex
+	keys      []Key
+	etag      string
+	expiresAt time.Time // cache freshness
+	lastFetch time.Time // last fetch attempt (rate limit)
+	lastOK    time.Time // last successful fetch or revalidation
+	lastErr   error
+}
+
+// NewRemoteProvider creates a JWKS-backed provider.
+//
+// No request is made until keys are needed; call Refresh to fetch
+// eagerly (e.g. at startup).
+func NewRemoteProvider(opts RemoteOptions) (*RemoteProvider, error) {
+	if !strings.HasPrefix(opts.URL, "https://") && !strings.HasPrefix(opts.URL, "http://") {
+		return nil, errors.New("keys: JWKS URL must be http(s)")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 5 * time.Minute
+	}
+	if opts.MaxTTL <= 0 {
+		opts.MaxTTL = 24 * time.Hour
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = 30 * time.Second
+	}
+	if opts.MaxStale <= 0 {
+		opts.MaxStale = 24 * time.Hour
+	}
+
+	return &RemoteProvider{opts: opts}, nil
+}
+
+// ActiveKey implements Provider. Remote keys cannot sign.
+func (p *RemoteProvider) ActiveKey() Key {
+	return Key{}
+}
+
+// VerificationKeys implements Provider (refreshing expired keys first).
+func (p *RemoteProvider) VerificationKeys() []Key {
+	p.refreshIfExpired()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.usable(time.Now()) {
+		return nil
+	}
+	return append([]Key(nil), p.keys...)
+}
+
+// KeyByID implements Provider.
+//
+// An unknown kid forces a refresh (at most once per MinRefreshInterval).
+func (p *RemoteProvider) KeyByID(id string) (Key, bool) {
+	p.refreshIfExpired()
+
+	if k, ok := p.lookup(id); ok {
+		return k, true
+	}
+
+	_ = p.fetch(context.Background(), p.rateLimitOK)
+	return p.lookup(id)
+}
+
+// Refresh fetches the key set now (conditionally, with the cached ETag).
+func (p *RemoteProvider) Refresh(ctx context.Context) error {
+	return p.fetch(ctx, nil)
+}
+
+// Health reports whether usable keys are available: nil when the
+// last fetch succeeded, the last error while serving stale keys
+// or when no keys could be loaded at all.
+func (p *RemoteProvider) Health() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	switch {
+	case p.lastOK.IsZero() && p.lastErr == nil:
+		return errors.New("keys: JWKS not fetched yet")
+	case p.lastErr != nil && !p.usable(time.Now()):
+		return fmt.Errorf("keys: JWKS unavailable: %w", p.lastErr)
+	default:
+		return p.lastErr
+	}
+}
+
+// lookup finds a cached, usable key by kid.
+func (p *RemoteProvider) lookup(id string) (Key, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.usable(time.Now()) {
+		return Key{}, false
+	}
+	for _, k := range p.keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// usable reports whether cached keys may be used (callers hold mu).
+func (p *RemoteProvider) usable(now time.Time) bool {
+	return !p.lastOK.IsZero() && now.Before(p.lastOK.Add(p.opts.MaxStale))
+}
+
+// refreshIfExpired fetches when the cache has expired.
+//
+// After a failed fetch, retries wait MinRefreshInterval so an
+// unreachable endpoint is not hit on every verification.
+func (p *RemoteProvider) refreshIfExpired() {
+	p.mu.RLock()
+	expired := p.expired(time.Now())
+	p.mu.RUnlock()
+
+	if expired {
+		_ = p.fetch(context.Background(), p.expired)
+	}
+}
+
+// expired reports whether the cache should be refreshed (callers hold mu).
+func (p *RemoteProvider) expired(now time.Time) bool {
+	return now.After(p.expiresAt) && p.rateLimitOK(now)
+}
+
+// rateLimitOK reports whether a fetch may be attempted (callers hold mu).
+func (p *RemoteProvider) rateLimitOK(now time.Time) bool {
+	return now.Sub(p.lastFetch) >= p.opts.MinRefreshInterval
+}
+
+// fetch downloads (or revalidates) the JWKS document.
+//
+// need (nil: always) is re-checked once the fetch lock is held, so
+// callers that waited for a concurrent fetch do not repeat it.
+func (p *RemoteProvider) fetch(
+	ctx context.Context,
+	need func(now time.Time) bool,
+) error {
+
+	p.fetchMu.Lock()
+	defer p.fetchMu.Unlock()
+
+	p.mu.Lock()
+	if need != nil && !need(time.Now()) {
+		p.mu.Unlock()
+		return nil
+	}
+	p.lastFetch = time.Now()
+	etag := p.etag
+	p.mu.Unlock()
+
+	keys, newETag, ttl, notModified, err := p.get(ctx, etag)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if err != nil {
+		p.lastErr = err
+		if p.opts.Metrics != nil {
+			p.opts.Metrics.JWKSFetchFailure()
+		}
+		return err
+	}
+
+	if !notModified {
+		p.keys = keys
+		p.etag = newETag
+	}
+	p.lastOK = now
+	p.lastErr = nil
+	p.expiresAt = now.Add(ttl)
+
+	if p.opts.Metrics != nil {
+		p.opts.Metrics.JWKSFetchSuccess()
+	}
+	return nil
+}
+
+// get performs the HTTP request and parses the key set.
+func (p *RemoteProvider) get(
+	ctx context.Context,
+	etag string,
+) (keys []Key, newETag string, ttl time.Duration, notModified bool, err error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.opts.URL, nil)
+	if err != nil {
+		return nil, "", 0, false, err
+	}
+	req.Header.Set("Accept", "application/json, application/jwk-set+json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", 0, false, fmt.Errorf("keys: JWKS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	ttl = p.cacheTTL(resp.Header.Get("Cache-Control"))
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, ttl, true, nil
+	case http.StatusOK:
+	default:
+		return nil, "", 0, false, fmt.Errorf("keys: JWKS endpoint returned %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, "", 0, false, errors.New("keys: invalid JWKS document")
+	}
+
+	keys = verificationKeysFrom(set)
+	if len(keys) == 0 {
+		return nil, "", 0, false, errors.New("keys: JWKS has no usable signing keys")
+	}
+
+	return keys, resp.Header.Get("ETag"), ttl, false, nil
+}
+
+// cacheTTL derives the cache lifetime from Cache-Control.
+func (p *RemoteProvider) cacheTTL(cacheControl string) time.Duration {
+	ttl := p.opts.TTL
+
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "no-cache", "no-store":
+			return p.opts.MinRefreshInterval
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && secs >= 0 {
+				ttl = time.Duration(secs) * time.Second
+			}
+		}
+	}
+
+	return min(max(ttl, p.opts.MinRefreshInterval), p.opts.MaxTTL)
+}
+
+// verificationKeysFrom converts the signing keys of a JWKS.
+//
+// Keys for other uses ("enc"), unsupported types and symmetric keys
+// are skipped rather than failing the whole set.
+func verificationKeysFrom(set JWKS) []Key {
+	var out []Key
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		alg := Algorithm(jwk.Alg)
+		if alg == "" {
+			alg = defaultAlgorithm(jwk.Kty)
+		}
+		if alg == "" || alg == HS256 || defaultAlgorithm(jwk.Kty) != alg {
+			continue
+		}
+
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+
+		out = append(out, Key{
+			ID:        jwk.Kid,
+			Algorithm: alg,
+			PublicKey: pub,
+		})
+	}
+
+	return out
+}
+
+// defaultAlgorithm maps a JWK key type to the algorithm this repo
+// verifies it with.
+func defaultAlgorithm(kty string) Algorithm {
+	switch kty {
+	case "RSA":
+		return RS256
+	case "EC":
+		return ES256
+	case "OKP":
+		return EdDSA
+	default:
+		return ""
+	}
+}
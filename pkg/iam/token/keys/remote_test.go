@@ -0,0 +1,232 @@
+package keys_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+)
+
+// jwksServer serves the public half of its keys as a JWKS document,
+// with an ETag per key set version.
+type jwksServer struct {
+	t *testing.T
+
+	mu           sync.Mutex
+	keys         []keys.Key
+	version      int
+	cacheControl string
+	fail         bool
+
+	requests    int // all requests
+	notModified int // 304 replies
+}
+
+func newJWKSServer(t *testing.T, cacheControl string, ks ...keys.Key) (*jwksServer, string) {
+	t.Helper()
+
+	s := &jwksServer{t: t, keys: ks, cacheControl: cacheControl}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv.URL
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if s.fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	etag := `"v` + strconv.Itoa(s.version) + `"`
+	w.Header().Set("Cache-Control", s.cacheControl)
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var set keys.JWKS
+	for _, k := range s.keys {
+		jwk, err := keys.PublicJWK(k)
+		if err != nil {
+			s.t.Errorf("PublicJWK: %v", err)
+			return
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+// publish replaces the key set (new ETag).
+func (s *jwksServer) publish(ks ...keys.Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = ks
+	s.version++
+}
+
+func (s *jwksServer) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fail = fail
+}
+
+func (s *jwksServer) counts() (requests, notModified int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests, s.notModified
+}
+
+func TestRemoteProviderCachesPerCacheControl(t *testing.T) {
+	srv, url := newJWKSServer(t, "public, max-age=3600", newTestKey(t, "k1", keys.ES256))
+
+	p := newTestRemoteProvider(t, keys.RemoteOptions{URL: url, MinRefreshInterval: time.Millisecond})
+
+	for range 3 {
+		if got := p.VerificationKeys(); len(got) != 1 || got[0].ID != "k1" {
+			t.Fatalf("VerificationKeys = %v, want [k1]", keyIDs(got))
+		}
+	}
+	if requests, _ := srv.counts(); requests != 1 {
+		t.Fatalf("requests = %d, want 1 (max-age not honored)", requests)
+	}
+}
+
+func TestRemoteProviderRevalidatesWithETag(t *testing.T) {
+	srv, url := newJWKSServer(t, "max-age=0", newTestKey(t, "k1", keys.ES256))
+
+	// max-age=0 is clamped to MinRefreshInterval.
+	p := newTestRemoteProvider(t, keys.RemoteOptions{URL: url, MinRefreshInterval: 20 * time.Millisecond})
+
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	if got := p.VerificationKeys(); len(got) != 1 || got[0].ID != "k1" {
+		t.Fatalf("VerificationKeys after 304 = %v, want [k1]", keyIDs(got))
+	}
+	if requests, notModified := srv.counts(); requests != 2 || notModified != 1 {
+		t.Fatalf("requests = %d, 304s = %d; want 2 requests, 1 revalidated", requests, notModified)
+	}
+
+	// A changed key set (new ETag) replaces the cached keys.
+	srv.publish(newTestKey(t, "k2", keys.EdDSA))
+	time.Sleep(30 * time.Millisecond)
+
+	if got := p.VerificationKeys(); len(got) != 1 || got[0].ID != "k2" {
+		t.Fatalf("VerificationKeys after change = %v, want [k2]", keyIDs(got))
+	}
+}
+
+func TestRemoteProviderRefetchesOnUnknownKeyID(t *testing.T) {
+	k1, k2 := newTestKey(t, "k1", keys.ES256), newTestKey(t, "k2", keys.ES256)
+	srv, url := newJWKSServer(t, "max-age=3600", k1)
+
+	p := newTestRemoteProvider(t, keys.RemoteOptions{URL: url, MinRefreshInterval: 10 * time.Millisecond})
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// The issuer rotates long before the cache expires.
+	srv.publish(k1, k2)
+	time.Sleep(20 * time.Millisecond)
+
+	got, ok := p.KeyByID("k2")
+	if !ok || got.ID != "k2" {
+		t.Fatal("KeyByID: rotated key not picked up")
+	}
+	if requests, _ := srv.counts(); requests != 2 {
+		t.Fatalf("requests = %d, want 2", requests)
+	}
+
+	// Known kids are served from the cache.
+	if _, ok := p.KeyByID("k1"); !ok {
+		t.Fatal("KeyByID: cached key missing")
+	}
+	if requests, _ := srv.counts(); requests != 2 {
+		t.Fatalf("requests = %d after cached lookup, want 2", requests)
+	}
+}
+
+func TestRemoteProviderRateLimitsRefetches(t *testing.T) {
+	srv, url := newJWKSServer(t, "max-age=3600", newTestKey(t, "k1", keys.ES256))
+
+	p := newTestRemoteProvider(t, keys.RemoteOptions{URL: url, MinRefreshInterval: time.Hour})
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Tokens with random kids must not hammer the endpoint.
+	for i := range 20 {
+		if _, ok := p.KeyByID("random-" + strconv.Itoa(i)); ok {
+			t.Fatal("KeyByID: unknown kid found")
+		}
+	}
+	if requests, _ := srv.counts(); requests != 1 {
+		t.Fatalf("requests = %d, want 1 (refetches not rate-limited)", requests)
+	}
+}
+
+func TestRemoteProviderServesStaleKeysOnFailure(t *testing.T) {
+	srv, url := newJWKSServer(t, "max-age=0", newTestKey(t, "k1", keys.ES256))
+
+	p := newTestRemoteProvider(t, keys.RemoteOptions{URL: url, MinRefreshInterval: 10 * time.Millisecond})
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	srv.setFail(true)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := p.KeyByID("k1"); !ok {
+		t.Fatal("KeyByID: last good keys not served while the endpoint fails")
+	}
+	if p.Health() == nil {
+		t.Fatal("Health: expected the fetch error")
+	}
+}
+
+func newTestRemoteProvider(t *testing.T, opts keys.RemoteOptions) *keys.RemoteProvider {
+	t.Helper()
+
+	p, err := keys.NewRemoteProvider(opts)
+	if err != nil {
+		t.Fatalf("NewRemoteProvider: %v", err)
+	}
+	return p
+}
+
+func newTestKey(t *testing.T, id string, alg keys.Algorithm) keys.Key {
+	t.Helper()
+
+	k, err := keys.Generate(id, alg)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return k
+}
+
+func keyIDs(ks []keys.Key) []string {
+	ids := make([]string, 0, len(ks))
+	for _, k := range ks {
+		ids = append(ids, k.ID)
+	}
+	return ids
+}
@@ -0,0 +1,112 @@
+package token
+
+import (
+	"errors"
+	"strings"
+)
+
+// ClaimMapping is a declarative ClaimMapper for external issuers.
+//
+// Roles, scopes and attributes are rebuilt ONLY from the claims
+// listed here: an external issuer cannot grant roles of this system
+// (e.g. "admin") just by putting them in its tokens.
+type ClaimMapping struct {
+	// SubjectPrefix namespaces external subjects so they never collide
+	// with local user IDs (e.g. "google|").
+	SubjectPrefix string
+
+	// RolesClaim is a (dot-separated) path to a string or string array
+	// claim holding roles, e.g. "groups" or "realm_access.roles".
+	// RoleMap translates external role names; when set, unmapped roles
+	// are dropped.
+	RolesClaim string
+	RoleMap    map[string]string
+
+	// DefaultRoles are granted to every subject of the issuer.
+	DefaultRoles []string
+
+	// ScopesClaim is the claim holding space-delimited scopes
+	// (e.g. "scope"); empty grants no scopes.
+	ScopesClaim string
+
+	// AttrClaims maps attribute names to claim paths,
+	// e.g. {"email": "email", "org": "hd"}.
+	AttrClaims map[string]string
+}
+
+// MapClaims implements ClaimMapper.
+func (m ClaimMapping) MapClaims(payload map[string]any, claims *Claims) error {
+	if claims.SubjectID == "" {
+		return errors.New("token: external token has no subject")
+	}
+	claims.SubjectID = m.SubjectPrefix + claims.SubjectID
+
+	roles := append([]string(nil), m.DefaultRoles...)
+	for _, r := range stringsAt(payload, m.RolesClaim) {
+		if m.RoleMap != nil {
+			mapped, ok := m.RoleMap[r]
+			if !ok {
+				continue
+			}
+			r = mapped
+		}
+		roles = append(roles, r)
+	}
+	claims.Roles = roles
+
+	claims.Scopes = nil
+	if m.ScopesClaim != "" {
+		if s, ok := valueAt(payload, m.ScopesClaim).(string); ok {
+			claims.Scopes = strings.Fields(s)
+		}
+	}
+
+	attrs := make(map[string]string, len(m.AttrClaims))
+	for name, path := range m.AttrClaims {
+		if s, ok := valueAt(payload, path).(string); ok && s != "" {
+			attrs[name] = s
+		}
+	}
+	claims.Attrs = attrs
+
+	// Sessions and delegation chains only exist for tokens issued here.
+	claims.SessionID = ""
+	claims.Actors = nil
+
+	return nil
+}
+
+// valueAt follows a dot-separated path into a JSON object.
+func valueAt(payload map[string]any, path string) any {
+	if path == "" {
+		return nil
+	}
+
+	var v any = payload
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
+
+// stringsAt reads a string or string-array claim.
+func stringsAt(payload map[string]any, path string) []string {
+	switch v := valueAt(payload, path).(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
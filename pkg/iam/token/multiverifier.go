@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/metrics"
 )
 
 // SingleVerifier verifies a token using ONE key.
@@ -12,24 +13,66 @@ type SingleVerifier interface {
 	VerifyWithKey(
 		ctx context.Context,
 		accessToken string,
-		key []byte,
+		key keys.Key,
 	) (*Claims, error)
 }
 
-// MultiVerifier tries multiple keys for verification.
+// KeyIDExtractor is implemented by SingleVerifiers that can read the
+// key ID ("kid") of a token WITHOUT verifying it.
+//
+// The kid is only a lookup hint: the token is still fully verified
+// with the selected key, so a forged kid can only make it fail.
+type KeyIDExtractor interface {
+	// KeyID returns the token kid, or false if the token carries none.
+	KeyID(accessToken string) (string, bool)
+}
+
+// MultiVerifier verifies tokens against a rotating set of keys.
+//
+// Lookup strategy:
+//   - kid present → verify with exactly that key (unknown kid fails fast)
+//   - kid absent  → legacy token, try every verification key
 type MultiVerifier struct {
 	Verifier    SingleVerifier
 	KeyProvider keys.Provider
+	Metrics     metrics.IAMMetrics // optional
 }
 
-// Verify tries verification using all available keys.
+// Verify selects the key by kid and verifies the token.
 func (m *MultiVerifier) Verify(
 	ctx context.Context,
 	accessToken string,
 ) (*Claims, error) {
 
+	if ext, ok := m.Verifier.(KeyIDExtractor); ok {
+		if kid, ok := ext.KeyID(accessToken); ok {
+			key, found := m.KeyProvider.KeyByID(kid)
+			if !found {
+				if m.Metrics != nil {
+					m.Metrics.TokenKeyUnknown()
+				}
+				return nil, errors.New("token verification failed: unknown key")
+			}
+
+			if m.Metrics != nil {
+				m.Metrics.TokenKeyLookup()
+			}
+
+			claims, err := m.Verifier.VerifyWithKey(ctx, accessToken, key)
+			if err != nil {
+				return nil, errors.New("token verification failed")
+			}
+			return claims, nil
+		}
+	}
+
+	// Legacy tokens without kid: O(keys) trial verification.
+	if m.Metrics != nil {
+		m.Metrics.TokenKeyScanFallback()
+	}
+
 	for _, k := range m.KeyProvider.VerificationKeys() {
-		claims, err := m.Verifier.VerifyWithKey(ctx, accessToken, k.Key)
+		claims, err := m.Verifier.VerifyWithKey(ctx, accessToken, k)
 		if err == nil {
 			return claims, nil
 		}
@@ -0,0 +1,80 @@
+// Package opaque implements reference (opaque) access tokens.
+//
+// The token is a random handle; its claims live in a Store on the
+// IAM side. Resource servers validate it locally (shared Store) or
+// via token introspection (RFC 7662).
+//
+// Compared to self-contained tokens (JWT / PASETO):
+//   - nothing about the subject is readable from the token
+//   - revocation takes effect immediately (delete from the Store)
+//   - every verification costs a Store lookup
+package opaque
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+)
+
+// TokenPrefix marks opaque access tokens, so they can be told
+// apart from JWT / PASETO tokens (and found by secret scanners).
+const TokenPrefix = "oat_"
+
+// Issuer implements token.Issuer with opaque tokens.
+type Issuer struct {
+	store Store
+	ttl   time.Duration
+}
+
+// NewIssuer creates an opaque token issuer.
+func NewIssuer(
+	store Store,
+	ttl time.Duration,
+) *Issuer {
+	return &Issuer{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Issue implements token.Issuer.
+func (i *Issuer) Issue(
+	ctx context.Context,
+	claims token.Claims,
+) (string, error) {
+
+	now := time.Now()
+
+	if claims.ID == "" {
+		id, err := token.NewTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.ID = id
+	}
+	claims.IssuedAt = now.Truncate(time.Second)
+	claims.ExpiresAt = token.Lifetime(now, i.ttl, claims.ExpiresAt).Truncate(time.Second)
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	tkn := TokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	if err := i.store.Save(ctx, digest(tkn), claims); err != nil {
+		return "", err
+	}
+
+	return tkn, nil
+}
+
+// digest is the Store key of a token.
+func digest(tkn string) string {
+	sum := sha256.Sum256([]byte(tkn))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package opaque
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+)
+
+// ErrTokenNotFound is returned for unknown or expired tokens.
+var ErrTokenNotFound = errors.New("opaque: token not found")
+
+// Store persists the claims of opaque access tokens.
+//
+// Tokens are keyed by their SHA-256 digest: a leaked store does not
+// leak usable tokens.
+type Store interface {
+
+	// Save stores claims under a token digest until claims.ExpiresAt.
+	Save(
+		ctx context.Context,
+		digest string,
+		claims token.Claims,
+	) error
+
+	// Get returns the claims of a token.
+	//
+	// Expected behavior:
+	//   - ErrTokenNotFound for unknown and expired tokens
+	Get(
+		ctx context.Context,
+		digest string,
+	) (*token.Claims, error)
+
+	// Delete removes a token (no error if it does not exist).
+	Delete(
+		ctx context.Context,
+		digest string,
+	) error
+}
+
+// memoryStore is a single-instance Store.
+//
+// TODO:
+//   - Shared (Redis / SQL) implementation for multiple replicas
+type memoryStore struct {
+	mu      sync.Mutex
+	tokens  map[string]token.Claims
+	inserts int
+}
+
+// NewMemoryStore creates an in-memory opaque token store.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		tokens: make(map[string]token.Claims),
+	}
+}
+
+// pruneEvery bounds how often expired tokens are swept (inserts).
+const pruneEvery = 1024
+
+func (s *memoryStore) Save(
+	ctx context.Context,
+	digest string,
+	claims token.Claims,
+) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[digest] = claims
+
+	s.inserts++
+	if s.inserts%pruneEvery == 0 {
+		now := time.Now()
+		for k, c := range s.tokens {
+			if !now.Before(c.ExpiresAt) {
+				delete(s.tokens, k)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Get(
+	ctx context.Context,
+	digest string,
+) (*token.Claims, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.tokens[digest]
+	if !ok || !time.Now().Before(c.ExpiresAt) {
+		return nil, ErrTokenNotFound
+	}
+
+	return &c, nil
+}
+
+func (s *memoryStore) Delete(
+	ctx context.Context,
+	digest string,
+) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, digest)
+	return nil
+}
@@ -0,0 +1,57 @@
+package opaque
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+)
+
+// Verifier implements token.Verifier by looking tokens up in a Store.
+type Verifier struct {
+	store    Store
+	audience string
+}
+
+// NewVerifier creates an opaque token verifier.
+//
+// audience is the "aud" value this service expects;
+// empty disables the audience check.
+func NewVerifier(
+	store Store,
+	audience string,
+) *Verifier {
+	return &Verifier{
+		store:    store,
+		audience: audience,
+	}
+}
+
+// Verify implements token.Verifier.
+func (v *Verifier) Verify(
+	ctx context.Context,
+	accessToken string,
+) (*token.Claims, error) {
+
+	if !strings.HasPrefix(accessToken, TokenPrefix) {
+		return nil, errors.New("opaque: not an opaque token")
+	}
+
+	claims, err := v.store.Get(ctx, digest(accessToken))
+	if err != nil {
+		return nil, errors.New("opaque: invalid token")
+	}
+
+	// Defensive: stores may return expired entries.
+	if !time.Now().Before(claims.ExpiresAt) {
+		return nil, errors.New("opaque: token expired")
+	}
+
+	if !claims.HasAudience(v.audience) {
+		return nil, errors.New("opaque: invalid audience")
+	}
+
+	return claims, nil
+}
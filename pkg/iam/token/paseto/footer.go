@@ -0,0 +1,45 @@
+package paseto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// v2LocalHeader is the PASETO v2.local token header.
+const v2LocalHeader = "v2.local."
+
+// footer is the (unencrypted, authenticated) PASETO footer.
+//
+// Only the key ID lives here so verifiers can select a key
+// before decrypting / verifying the token.
+type footer struct {
+	KeyID string `json:"kid"`
+}
+
+// footerKeyID reads the kid from a token footer WITHOUT verifying it.
+//
+// Returns false for other token versions or tokens without a kid
+// (legacy v2.local tokens carried the kid inside the encrypted payload).
+func footerKeyID(accessToken, header string) (string, bool) {
+	if !strings.HasPrefix(accessToken, header) {
+		return "", false
+	}
+
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 4 {
+		return "", false
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", false
+	}
+
+	var f footer
+	if err := json.Unmarshal(raw, &f); err != nil || f.KeyID == "" {
+		return "", false
+	}
+
+	return f.KeyID, true
+}
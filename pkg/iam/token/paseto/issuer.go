@@ -8,41 +8,40 @@ import (
 	"github.com/o1egl/paseto"
 
 	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
 )
 
 // Issuer implements token.Issuer using PASETO v2.local.
 //
-// Tokens are encrypted (not signed).
-// Key rotation is supported via embedded key ID ("kid").
+// Tokens are encrypted (not signed) with the active key of a
+// keys.Provider, read on every Issue.
+// Key rotation is supported via the key ID ("kid") in the footer.
 type Issuer struct {
 	paseto *paseto.V2
-	key    []byte
-	keyID  string
+	keys   keys.Provider
 	issuer string
 	ttl    time.Duration
 }
 
 // NewIssuer creates a PASETO v2.local issuer.
 //
-// key MUST be 32 bytes.
+// Keys MUST be 32-byte symmetric keys.
 //
-// TODO (prod):
-//   - Support v4.public
+// For tokens that third parties verify without the secret,
+// use NewPublicIssuer (v4.public).
 func NewIssuer(
-	key []byte,
-	keyID string,
+	kp keys.Provider,
 	issuer string,
 	ttl time.Duration,
 ) (*Issuer, error) {
 
-	if len(key) != 32 {
-		return nil, errors.New("paseto: key must be 32 bytes")
+	if _, err := localKey(kp.ActiveKey()); err != nil {
+		return nil, err
 	}
 
 	return &Issuer{
 		paseto: paseto.NewV2(),
-		key:    key,
-		keyID:  keyID,
+		keys:   kp,
 		issuer: issuer,
 		ttl:    ttl,
 	}, nil
@@ -54,17 +53,29 @@ func (i *Issuer) Issue(
 	claims token.Claims,
 ) (string, error) {
 
+	key := i.keys.ActiveKey()
+	secret, err := localKey(key)
+	if err != nil {
+		return "", err
+	}
+
 	now := time.Now()
 
+	if claims.ID == "" {
+		id, err := token.NewTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.ID = id
+	}
+
 	payload := map[string]any{
 		"iss": i.issuer,
 		"sub": claims.SubjectID,
 		"iat": now.Unix(),
-		"exp": now.Add(i.ttl).Unix(),
-
-		// 🔑 Key rotation support
-		"kid": i.keyID,
+		"exp": token.Lifetime(now, i.ttl, claims.ExpiresAt).Unix(),
 	}
+	token.SetRegistered(payload, claims)
 
 	if len(claims.Roles) > 0 {
 		payload["roles"] = claims.Roles
@@ -72,11 +83,24 @@ func (i *Issuer) Issue(
 	if len(claims.Attrs) > 0 {
 		payload["attrs"] = claims.Attrs
 	}
+	token.SetConfirmation(payload, claims.Confirmation)
 
-	tkn, err := i.paseto.Encrypt(i.key, payload, nil)
+	// 🔑 Key rotation support (kid in footer: authenticated, readable before decryption)
+	tkn, err := i.paseto.Encrypt(secret, payload, footer{KeyID: key.ID})
 	if err != nil {
 		return "", err
 	}
 
 	return tkn, nil
 }
+
+// localKey returns the v2.local key material of key.
+func localKey(key keys.Key) ([]byte, error) {
+	if key.ID == "" {
+		return nil, errors.New("paseto: no active key")
+	}
+	if !key.IsSymmetric() || len(key.Key) != 32 {
+		return nil, errors.New("paseto: key must be 32 bytes")
+	}
+	return key.Key, nil
+}
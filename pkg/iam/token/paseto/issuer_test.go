@@ -0,0 +1,31 @@
+package paseto_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+	"github.com/kararnab/authdemo/pkg/iam/token/paseto"
+	"github.com/kararnab/authdemo/pkg/iam/token/tokentest"
+)
+
+func TestLocalIssuerRotation(t *testing.T) {
+	tokentest.RunIssuerRotationTests(t, tokentest.IssuerSuite{
+		Algorithm: keys.HS256, // 32-byte symmetric key
+		NewIssuer: func(kp keys.Provider) (token.Issuer, error) {
+			return paseto.NewIssuer(kp, "test", time.Minute)
+		},
+		Verifier: paseto.NewVerifier("test", ""),
+	})
+}
+
+func TestPublicIssuerRotation(t *testing.T) {
+	tokentest.RunIssuerRotationTests(t, tokentest.IssuerSuite{
+		Algorithm: keys.EdDSA,
+		NewIssuer: func(kp keys.Provider) (token.Issuer, error) {
+			return paseto.NewPublicIssuer(kp, "test", time.Minute)
+		},
+		Verifier: paseto.NewPublicVerifier("test", ""),
+	})
+}
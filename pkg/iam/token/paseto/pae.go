@@ -0,0 +1,30 @@
+package paseto
+
+import "encoding/binary"
+
+// pae implements PASETO Pre-Authentication Encoding.
+//
+// PAE binds header, payload, footer and implicit assertion together
+// so none of them can be swapped without breaking the signature.
+//
+// See: https://github.com/paseto-standard/paseto-spec/blob/master/docs/01-Protocol-Versions/Common.md
+func pae(pieces ...[]byte) []byte {
+	size := 8
+	for _, p := range pieces {
+		size += 8 + len(p)
+	}
+
+	out := make([]byte, 0, size)
+	out = le64(out, uint64(len(pieces)))
+	for _, p := range pieces {
+		out = le64(out, uint64(len(p)))
+		out = append(out, p...)
+	}
+	return out
+}
+
+// le64 appends n as unsigned little-endian 64-bit integer
+// with the most significant bit cleared (per spec).
+func le64(b []byte, n uint64) []byte {
+	return binary.LittleEndian.AppendUint64(b, n&^(1<<63))
+}
@@ -0,0 +1,123 @@
+package paseto
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+)
+
+// v4PublicHeader is the PASETO v4.public token header.
+const v4PublicHeader = "v4.public."
+
+// PublicIssuer implements token.Issuer using PASETO v4.public.
+//
+// Tokens are signed with Ed25519 (not encrypted):
+// partners verify with the public key but can neither mint
+// nor read anything that is not already in the clear.
+// The active key of the keys.Provider is read on every Issue.
+type PublicIssuer struct {
+	keys   keys.Provider
+	issuer string
+	ttl    time.Duration
+}
+
+// NewPublicIssuer creates a PASETO v4.public issuer.
+//
+// Keys MUST be EdDSA keys carrying their private half.
+func NewPublicIssuer(
+	kp keys.Provider,
+	issuer string,
+	ttl time.Duration,
+) (*PublicIssuer, error) {
+
+	if _, err := publicSigningKey(kp.ActiveKey()); err != nil {
+		return nil, err
+	}
+
+	return &PublicIssuer{
+		keys:   kp,
+		issuer: issuer,
+		ttl:    ttl,
+	}, nil
+}
+
+// Issue implements token.Issuer.
+func (i *PublicIssuer) Issue(
+	ctx context.Context,
+	claims token.Claims,
+) (string, error) {
+
+	key := i.keys.ActiveKey()
+	priv, err := publicSigningKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	if claims.ID == "" {
+		id, err := token.NewTokenID()
+		if err != nil {
+			return "", err
+		}
+		claims.ID = id
+	}
+
+	// Registered claims use RFC 3339 timestamps (PASETO spec).
+	payload := map[string]any{
+		"iss": i.issuer,
+		"sub": claims.SubjectID,
+		"iat": now.UTC().Format(time.RFC3339),
+		"exp": token.Lifetime(now, i.ttl, claims.ExpiresAt).UTC().Format(time.RFC3339),
+	}
+	token.SetRegistered(payload, claims)
+
+	if len(claims.Roles) > 0 {
+		payload["roles"] = claims.Roles
+	}
+	if len(claims.Attrs) > 0 {
+		payload["attrs"] = claims.Attrs
+	}
+	token.SetConfirmation(payload, claims.Confirmation)
+
+	m, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	// 🔑 Key rotation support (kid in footer, readable before verification)
+	f, err := json.Marshal(footer{KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+
+	sig := ed25519.Sign(priv, pae([]byte(v4PublicHeader), m, f, nil))
+
+	body := append(m, sig...)
+
+	return v4PublicHeader +
+		base64.RawURLEncoding.EncodeToString(body) + "." +
+		base64.RawURLEncoding.EncodeToString(f), nil
+}
+
+// publicSigningKey returns the Ed25519 private key of key.
+func publicSigningKey(key keys.Key) (ed25519.PrivateKey, error) {
+	if key.ID == "" {
+		return nil, errors.New("paseto: no active key")
+	}
+	if key.Alg() != keys.EdDSA {
+		return nil, errors.New("paseto: v4.public requires an EdDSA key")
+	}
+
+	priv, ok := key.PrivateKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("paseto: v4.public requires an Ed25519 private key")
+	}
+	return priv, nil
+}
@@ -0,0 +1,155 @@
+package paseto
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+)
+
+// PublicVerifier verifies PASETO v4.public tokens using a single public key.
+//
+// It holds no secrets and is safe to hand to partner services.
+type PublicVerifier struct {
+	issuer   string
+	audience string
+}
+
+// NewPublicVerifier creates a PASETO v4.public verifier.
+//
+// audience is the "aud" value this service expects;
+// empty disables the audience check.
+func NewPublicVerifier(
+	issuer string,
+	audience string,
+) *PublicVerifier {
+	return &PublicVerifier{
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+// KeyID implements token.KeyIDExtractor (reads the footer, no verification).
+func (v *PublicVerifier) KeyID(accessToken string) (string, bool) {
+	return footerKeyID(accessToken, v4PublicHeader)
+}
+
+// VerifyWithKey verifies a v4.public token signature and its claims.
+func (v *PublicVerifier) VerifyWithKey(
+	ctx context.Context,
+	accessToken string,
+	key keys.Key,
+) (*token.Claims, error) {
+
+	pub, ok := key.PublicKey.(ed25519.PublicKey)
+	if !ok || key.Alg() != keys.EdDSA {
+		return nil, errors.New("paseto: v4.public requires an Ed25519 public key")
+	}
+
+	m, f, err := openPublic(accessToken, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	// kid is authenticated (part of PAE); a mismatch means wrong key.
+	if len(f) > 0 {
+		var ft footer
+		if err := json.Unmarshal(f, &ft); err == nil && ft.KeyID != "" && key.ID != "" && ft.KeyID != key.ID {
+			return nil, errors.New("paseto: key id mismatch")
+		}
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(m, &payload); err != nil {
+		return nil, errors.New("paseto: invalid payload")
+	}
+
+	iss, ok := payload["iss"].(string)
+	if !ok || iss != v.issuer {
+		return nil, errors.New("paseto: invalid issuer")
+	}
+
+	rawExp, ok := payload["exp"].(string)
+	if !ok {
+		return nil, errors.New("paseto: missing exp")
+	}
+	exp, err := time.Parse(time.RFC3339, rawExp)
+	if err != nil {
+		return nil, errors.New("paseto: invalid exp")
+	}
+	if time.Now().After(exp) {
+		return nil, errors.New("paseto: token expired")
+	}
+
+	sub, ok := payload["sub"].(string)
+	if !ok || sub == "" {
+		return nil, errors.New("paseto: missing subject")
+	}
+
+	var iat time.Time
+	if rawIat, ok := payload["iat"].(string); ok {
+		iat, _ = time.Parse(time.RFC3339, rawIat)
+	}
+
+	roles, attrs := rolesAndAttrs(payload)
+
+	claims := &token.Claims{
+		SubjectID:    sub,
+		Roles:        roles,
+		Attrs:        attrs,
+		Confirmation: token.ConfirmationFrom(payload),
+		IssuedAt:     iat,
+		ExpiresAt:    exp,
+	}
+	token.RegisteredFrom(payload, claims)
+
+	if !claims.HasAudience(v.audience) {
+		return nil, errors.New("paseto: invalid audience")
+	}
+
+	return claims, nil
+}
+
+// openPublic checks a v4.public signature and returns message and footer.
+func openPublic(
+	accessToken string,
+	pub ed25519.PublicKey,
+) ([]byte, []byte, error) {
+
+	if !strings.HasPrefix(accessToken, v4PublicHeader) {
+		return nil, nil, errors.New("paseto: not a v4.public token")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(accessToken, v4PublicHeader), ".")
+	if len(parts) < 1 || len(parts) > 2 {
+		return nil, nil, errors.New("paseto: malformed token")
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return nil, nil, errors.New("paseto: malformed token")
+	}
+
+	var f []byte
+	if len(parts) == 2 {
+		f, err = base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, nil, errors.New("paseto: malformed footer")
+		}
+	}
+
+	split := len(body) - ed25519.SignatureSize
+	m, sig := body[:split], body[split:]
+
+	if !ed25519.Verify(pub, pae([]byte(v4PublicHeader), m, f, nil), sig) {
+		return nil, nil, errors.New("paseto: invalid token")
+	}
+
+	return m, f, nil
+}
@@ -8,36 +8,52 @@ import (
 	"github.com/o1egl/paseto"
 
 	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
 )
 
 // Verifier verifies PASETO v2.local tokens using a single key.
 type Verifier struct {
-	paseto *paseto.V2
-	issuer string
+	paseto   *paseto.V2
+	issuer   string
+	audience string
 }
 
 // NewVerifier creates a PASETO verifier.
+//
+// audience is the "aud" value this service expects;
+// empty disables the audience check.
 func NewVerifier(
 	issuer string,
+	audience string,
 ) *Verifier {
 	return &Verifier{
-		paseto: paseto.NewV2(),
-		issuer: issuer,
+		paseto:   paseto.NewV2(),
+		issuer:   issuer,
+		audience: audience,
 	}
 }
 
+// KeyID implements token.KeyIDExtractor (reads the footer, no decryption).
+func (v *Verifier) KeyID(accessToken string) (string, bool) {
+	return footerKeyID(accessToken, v2LocalHeader)
+}
+
 // VerifyWithKey decrypts and verifies a PASETO token using the provided key.
 func (v *Verifier) VerifyWithKey(
 	ctx context.Context,
 	accessToken string,
-	key []byte,
+	key keys.Key,
 ) (*token.Claims, error) {
 
+	if !key.IsSymmetric() {
+		return nil, errors.New("paseto: v2.local requires a symmetric key")
+	}
+
 	var payload map[string]any
 
 	if err := v.paseto.Decrypt(
 		accessToken,
-		key,
+		key.Key,
 		&payload,
 		nil,
 	); err != nil {
@@ -62,6 +78,30 @@ func (v *Verifier) VerifyWithKey(
 		return nil, errors.New("paseto: missing subject")
 	}
 
+	iat, _ := payload["iat"].(float64)
+
+	roles, attrs := rolesAndAttrs(payload)
+
+	claims := &token.Claims{
+		SubjectID:    sub,
+		Roles:        roles,
+		Attrs:        attrs,
+		Confirmation: token.ConfirmationFrom(payload),
+		IssuedAt:     time.Unix(int64(iat), 0),
+		ExpiresAt:    time.Unix(int64(exp), 0),
+	}
+	token.RegisteredFrom(payload, claims)
+
+	if !claims.HasAudience(v.audience) {
+		return nil, errors.New("paseto: invalid audience")
+	}
+
+	return claims, nil
+}
+
+// rolesAndAttrs extracts the optional roles / attrs claims.
+func rolesAndAttrs(payload map[string]any) ([]string, map[string]string) {
+
 	// Optional roles
 	var roles []string
 	if r, ok := payload["roles"].([]any); ok {
@@ -82,9 +122,5 @@ func (v *Verifier) VerifyWithKey(
 		}
 	}
 
-	return &token.Claims{
-		SubjectID: sub,
-		Roles:     roles,
-		Attrs:     attrs,
-	}, nil
+	return roles, attrs
 }
@@ -0,0 +1,114 @@
+package pop
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DPoPProofType is the "typ" header of DPoP proofs (RFC 9449 §4.2).
+const DPoPProofType = "dpop+jwt"
+
+// DPoPVerifier checks DPoP proofs (RFC 9449).
+//
+// It is transport-agnostic: callers pass the HTTP method and the
+// target URI as the client saw it.
+//
+// TODO:
+//   - Server-provided nonces (DPoP-Nonce)
+type DPoPVerifier struct {
+	Replay ReplayCache   // required: rejects reused proof IDs (jti)
+	MaxAge time.Duration // proof freshness window (default 60s)
+}
+
+// DPoPRequest is the request a proof is checked against.
+type DPoPRequest struct {
+	Method string // htm
+	URL    string // htu: scheme://host/path (query and fragment ignored)
+
+	// AccessToken is set when the proof accompanies an access token
+	// (resource requests): the proof must carry its hash (ath).
+	AccessToken string
+
+	// Thumbprint, if set, is the key the proof must be signed with
+	// (cnf.jkt of the access token).
+	Thumbprint string
+}
+
+// Verify validates a DPoP proof and returns it.
+func (v *DPoPVerifier) Verify(
+	ctx context.Context,
+	proof string,
+	req DPoPRequest,
+) (*Proof, error) {
+
+	if v.Replay == nil {
+		return nil, errors.New("pop: DPoP replay cache is required")
+	}
+
+	maxAge := v.MaxAge
+	if maxAge <= 0 {
+		maxAge = time.Minute
+	}
+
+	p, err := Verify(proof, VerifyOptions{
+		Type:   DPoPProofType,
+		MaxAge: maxAge,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.StringClaim("htm") != req.Method {
+		return nil, fmt.Errorf("%w: htm mismatch", ErrInvalidProof)
+	}
+	if !sameHTU(p.StringClaim("htu"), req.URL) {
+		return nil, fmt.Errorf("%w: htu mismatch", ErrInvalidProof)
+	}
+
+	if req.AccessToken != "" {
+		if p.StringClaim("ath") != Hash(req.AccessToken) {
+			return nil, fmt.Errorf("%w: ath mismatch", ErrInvalidProof)
+		}
+	}
+	if req.Thumbprint != "" &&
+		subtle.ConstantTimeCompare([]byte(p.Thumbprint), []byte(req.Thumbprint)) != 1 {
+		return nil, fmt.Errorf("%w: key does not match token binding", ErrInvalidProof)
+	}
+
+	// Replay check last: only well-formed proofs occupy the cache.
+	jti := p.StringClaim("jti")
+	if jti == "" {
+		return nil, fmt.Errorf("%w: missing jti", ErrInvalidProof)
+	}
+	seen, err := v.Replay.Seen(ctx, p.Thumbprint+":"+jti, p.IssuedAt.Add(maxAge+clockSkew))
+	if err != nil {
+		return nil, err
+	}
+	if seen {
+		return nil, fmt.Errorf("%w: replayed proof", ErrInvalidProof)
+	}
+
+	return p, nil
+}
+
+// sameHTU compares URIs per RFC 9449 §4.3: scheme and host are
+// case-insensitive, query and fragment are ignored.
+func sameHTU(claimed, actual string) bool {
+	a, err := url.Parse(claimed)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(actual)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(a.Scheme, b.Scheme) &&
+		strings.EqualFold(a.Host, b.Host) &&
+		a.EscapedPath() == b.EscapedPath()
+}
@@ -0,0 +1,132 @@
+// Package pop verifies proof-of-possession JWTs.
+//
+// A proof is a short-lived JWS signed by a client-held private key
+// whose public JWK travels in the "jwk" header. Holding a bearer
+// credential (refresh token, access token) is then not enough:
+// the caller must also prove possession of the bound key.
+//
+// Used for device-bound refresh tokens and DPoP (RFC 9449).
+package pop
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+)
+
+// ErrInvalidProof is returned for any malformed, unsigned, stale
+// or otherwise unacceptable proof.
+var ErrInvalidProof = errors.New("pop: invalid proof")
+
+// Asymmetric algorithms accepted for proofs ("none" and HMAC never are).
+var validMethods = []string{
+	string(keys.ES256),
+	string(keys.EdDSA),
+	string(keys.RS256),
+}
+
+// Proof is a verified proof-of-possession JWT.
+type Proof struct {
+	JWK        keys.JWK
+	Thumbprint string // RFC 7638 thumbprint of JWK ("jkt")
+	IssuedAt   time.Time
+	Claims     map[string]any
+}
+
+// VerifyOptions configures Verify.
+type VerifyOptions struct {
+	Type   string        // required "typ" header (e.g. "dpop+jwt")
+	MaxAge time.Duration // iat freshness window (default 60s)
+}
+
+// clockSkew tolerates client clocks slightly ahead of ours.
+const clockSkew = 5 * time.Second
+
+// Verify checks the proof signature against its embedded JWK,
+// its type and its freshness.
+//
+// Callers still have to compare Thumbprint with the bound key and
+// check request-specific claims (htm / htu / ath, rt_hash, ...).
+func Verify(proof string, opts VerifyOptions) (*Proof, error) {
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = time.Minute
+	}
+
+	var jwk keys.JWK
+
+	parsed, err := gojwt.Parse(
+		proof,
+		func(t *gojwt.Token) (any, error) {
+			if typ, _ := t.Header["typ"].(string); typ != opts.Type {
+				return nil, fmt.Errorf("unexpected typ %q", typ)
+			}
+
+			raw, ok := t.Header["jwk"].(map[string]any)
+			if !ok {
+				return nil, errors.New("missing jwk header")
+			}
+			for _, private := range []string{"d", "p", "q", "dp", "dq", "qi", "k"} {
+				if _, ok := raw[private]; ok {
+					return nil, errors.New("jwk header must not contain private key material")
+				}
+			}
+
+			b, err := json.Marshal(raw)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(b, &jwk); err != nil {
+				return nil, err
+			}
+			return jwk.PublicKey()
+		},
+		gojwt.WithValidMethods(validMethods),
+		gojwt.WithoutClaimsValidation(), // iat handled below with our window
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+
+	claims, _ := parsed.Claims.(gojwt.MapClaims)
+
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return nil, fmt.Errorf("%w: missing iat", ErrInvalidProof)
+	}
+	now := time.Now()
+	if iat.After(now.Add(clockSkew)) || now.Sub(iat.Time) > opts.MaxAge {
+		return nil, fmt.Errorf("%w: stale or future iat", ErrInvalidProof)
+	}
+
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+
+	return &Proof{
+		JWK:        jwk,
+		Thumbprint: thumbprint,
+		IssuedAt:   iat.Time,
+		Claims:     claims,
+	}, nil
+}
+
+// StringClaim returns a string claim ("" if missing or not a string).
+func (p *Proof) StringClaim(name string) string {
+	s, _ := p.Claims[name].(string)
+	return s
+}
+
+// Hash returns base64url(SHA-256(s)), the form used to bind a proof
+// to a token (DPoP "ath", device proof "rt_hash").
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,74 @@
+package pop
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReplayCache remembers proof IDs (jti) until they expire.
+//
+// A proof is only fresh for VerifyOptions.MaxAge, so entries never
+// need to outlive that window.
+type ReplayCache interface {
+
+	// Seen records jti and reports whether it was already recorded.
+	//
+	// Expected behavior:
+	//   - Atomic: of two concurrent calls with the same jti, one wins
+	//   - Entries may be dropped after expiresAt
+	Seen(
+		ctx context.Context,
+		jti string,
+		expiresAt time.Time,
+	) (bool, error)
+}
+
+// memoryReplayCache is a single-instance ReplayCache.
+//
+// TODO:
+//   - Shared (Redis) implementation for multiple replicas
+type memoryReplayCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	inserts int
+}
+
+// NewMemoryReplayCache creates an in-memory replay cache.
+func NewMemoryReplayCache() ReplayCache {
+	return &memoryReplayCache{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// pruneEvery bounds how often expired entries are swept (inserts).
+const pruneEvery = 1024
+
+func (c *memoryReplayCache) Seen(
+	ctx context.Context,
+	jti string,
+	expiresAt time.Time,
+) (bool, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	if exp, ok := c.entries[jti]; ok && now.Before(exp) {
+		return true, nil
+	}
+
+	c.entries[jti] = expiresAt
+
+	c.inserts++
+	if c.inserts%pruneEvery == 0 {
+		for k, exp := range c.entries {
+			if !now.Before(exp) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,67 @@
+package revocation
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloomFilter is a fixed-size Bloom filter over strings.
+//
+// "Not present" answers are exact; "present" answers may be false
+// positives and must be confirmed against the Store.
+// Entries cannot be removed: the filter is rebuilt instead.
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+}
+
+// newBloomFilter sizes a filter for n entries at false positive rate p.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = max(k, 1)
+
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func (b *bloomFilter) add(s string) {
+	h1, h2 := bloomHashes(s)
+	for i := range b.k {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *bloomFilter) mayContain(s string) bool {
+	h1, h2 := bloomHashes(s)
+	for i := range b.k {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes derives two hashes for double hashing
+// (Kirsch–Mitzenmacher): h_i = h1 + i*h2.
+func bloomHashes(s string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	h1 := h.Sum64()
+
+	h2 := (h1 >> 33) | (h1 << 31)
+	h2 |= 1 // odd: never a zero stride
+
+	return h1, h2
+}
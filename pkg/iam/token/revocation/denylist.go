@@ -0,0 +1,237 @@
+// Package revocation lets stateless access tokens be revoked
+// before they expire.
+//
+// Revoked token IDs (jti) and session IDs (sid) are kept in a
+// denylist until the tokens they cover have expired anyway.
+// Verification consults an in-memory Bloom filter first, so the
+// common case (token not revoked) never reaches the Store.
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/log"
+)
+
+// Denylist key namespaces.
+const (
+	tokenPrefix   = "jti:"
+	sessionPrefix = "sid:"
+)
+
+// Options configures a Denylist.
+type Options struct {
+	// ExpectedEntries sizes the Bloom filter (default 10000);
+	// the filter grows on rebuild if there are more.
+	ExpectedEntries int
+
+	// FalsePositiveRate of the filter (default 0.01): the share of
+	// valid tokens that still need a Store lookup.
+	FalsePositiveRate float64
+
+	// RefreshInterval bounds how long the filter goes without being
+	// rebuilt from the Store (default 1m). Rebuilds drop expired
+	// entries and pick up revocations made by other replicas.
+	// A failed rebuild is retried after RefreshInterval too, so a
+	// Store outage does not add Store round trips to every request.
+	RefreshInterval time.Duration
+}
+
+// Denylist records revoked access tokens.
+//
+// Consistency: revocations made through this Denylist apply
+// immediately; with several replicas sharing a persistent Store,
+// revocations made elsewhere apply after the next refresh
+// (RefreshInterval).
+type Denylist struct {
+	store Store
+	opts  Options
+
+	mu          sync.RWMutex
+	filter      *bloomFilter
+	refreshedAt time.Time
+	failedAt    time.Time // last failed rebuild (retry back-off)
+	added       []string  // keys added since the running rebuild started
+
+	refreshing sync.Mutex // one rebuild at a time
+}
+
+// New creates a Denylist and loads the current entries of store.
+func New(
+	ctx context.Context,
+	store Store,
+	opts Options,
+) (*Denylist, error) {
+
+	if opts.ExpectedEntries <= 0 {
+		opts.ExpectedEntries = 10000
+	}
+	if opts.FalsePositiveRate <= 0 || opts.FalsePositiveRate >= 1 {
+		opts.FalsePositiveRate = 0.01
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = time.Minute
+	}
+
+	d := &Denylist{
+		store: store,
+		opts:  opts,
+	}
+	if err := d.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// RevokeToken denies one access token until its expiry.
+func (d *Denylist) RevokeToken(
+	ctx context.Context,
+	tokenID string,
+	expiresAt time.Time,
+) error {
+	return d.add(ctx, tokenPrefix+tokenID, expiresAt)
+}
+
+// RevokeSession denies every access token issued for a session
+// (sid) until expiresAt, which must cover the longest-lived of them.
+func (d *Denylist) RevokeSession(
+	ctx context.Context,
+	sessionID string,
+	expiresAt time.Time,
+) error {
+	return d.add(ctx, sessionPrefix+sessionID, expiresAt)
+}
+
+// IsRevoked reports whether the token or its session was revoked.
+func (d *Denylist) IsRevoked(
+	ctx context.Context,
+	claims *token.Claims,
+) (bool, error) {
+
+	d.maybeRefresh(ctx)
+
+	var keys []string
+	if claims.ID != "" {
+		keys = append(keys, tokenPrefix+claims.ID)
+	}
+	if claims.SessionID != "" {
+		keys = append(keys, sessionPrefix+claims.SessionID)
+	}
+
+	now := time.Now()
+	for _, k := range keys {
+		d.mu.RLock()
+		maybe := d.filter.mayContain(k)
+		d.mu.RUnlock()
+		if !maybe {
+			continue // definitely not revoked
+		}
+
+		revoked, err := d.store.Contains(ctx, k, now)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	return false, nil
+}
+
+// Refresh drops expired entries and rebuilds the filter from the Store.
+func (d *Denylist) Refresh(ctx context.Context) error {
+	d.refreshing.Lock()
+	defer d.refreshing.Unlock()
+
+	return d.refresh(ctx)
+}
+
+func (d *Denylist) add(
+	ctx context.Context,
+	key string,
+	expiresAt time.Time,
+) error {
+
+	if !time.Now().Before(expiresAt) {
+		return nil // nothing left to revoke
+	}
+
+	if err := d.store.Add(ctx, key, expiresAt); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.filter.add(key)
+	if d.added != nil {
+		d.added = append(d.added, key)
+	}
+	d.mu.Unlock()
+
+	return nil
+}
+
+// maybeRefresh rebuilds a stale filter inline. Concurrent callers
+// do not wait: they keep using the current filter.
+func (d *Denylist) maybeRefresh(ctx context.Context) {
+	d.mu.RLock()
+	stale := time.Since(d.refreshedAt) >= d.opts.RefreshInterval &&
+		time.Since(d.failedAt) >= d.opts.RefreshInterval
+	d.mu.RUnlock()
+
+	if !stale || !d.refreshing.TryLock() {
+		return
+	}
+	defer d.refreshing.Unlock()
+
+	if err := d.refresh(ctx); err != nil {
+		log.Warn(
+			"denylist refresh failed",
+			log.F("error", err, log.RedactNone),
+		)
+	}
+}
+
+// refresh requires d.refreshing.
+func (d *Denylist) refresh(ctx context.Context) (err error) {
+	now := time.Now()
+
+	// Keys added from here on may be missing from ListActive:
+	// record them and replay them into the new filter.
+	d.mu.Lock()
+	d.added = []string{}
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.added = nil
+		if err != nil {
+			d.failedAt = time.Now()
+		}
+		d.mu.Unlock()
+	}()
+
+	if _, err := d.store.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+
+	ids, err := d.store.ListActive(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	filter := newBloomFilter(max(d.opts.ExpectedEntries, 2*len(ids)), d.opts.FalsePositiveRate)
+	for _, id := range ids {
+		filter.add(id)
+	}
+
+	d.mu.Lock()
+	for _, k := range d.added {
+		filter.add(k)
+	}
+	d.filter = filter
+	d.refreshedAt = now
+	d.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package revocation_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/revocation"
+)
+
+// flakyStore fails rebuild calls while down and counts them.
+type flakyStore struct {
+	revocation.Store
+
+	mu       sync.Mutex
+	down     bool
+	rebuilds int // DeleteExpired calls
+}
+
+func (s *flakyStore) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.down = down
+}
+
+func (s *flakyStore) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rebuilds
+}
+
+func (s *flakyStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	s.rebuilds++
+	down := s.down
+	s.mu.Unlock()
+
+	if down {
+		return 0, errors.New("store unavailable")
+	}
+	return s.Store.DeleteExpired(ctx, now)
+}
+
+func TestDenylistBacksOffAfterFailedRefresh(t *testing.T) {
+	ctx := context.Background()
+	store := &flakyStore{Store: revocation.NewMemoryStore()}
+
+	d, err := revocation.New(ctx, store, revocation.Options{RefreshInterval: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	store.setDown(true)
+	time.Sleep(60 * time.Millisecond)
+
+	claims := &token.Claims{ID: "jti-1", SessionID: "sid-1"}
+	for range 10 {
+		if revoked, err := d.IsRevoked(ctx, claims); err != nil || revoked {
+			t.Fatalf("IsRevoked = %v, %v; want false, nil", revoked, err)
+		}
+	}
+	// Open plus one failed inline refresh, not one per verification.
+	if got := store.calls(); got != 2 {
+		t.Fatalf("refresh attempts = %d, want 2", got)
+	}
+
+	// Retried once the back-off has passed.
+	store.setDown(false)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := d.IsRevoked(ctx, claims); err != nil {
+		t.Fatalf("IsRevoked: %v", err)
+	}
+	if got := store.calls(); got != 3 {
+		t.Fatalf("refresh attempts = %d, want 3", got)
+	}
+}
@@ -0,0 +1,159 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect selects SQL flavour differences (placeholders).
+//
+// The schema sticks to portable types, so the same migration runs
+// on SQLite and Postgres.
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// sqlStore is a database/sql implementation of Store.
+//
+// Usually shares the database of the session store; expiry is
+// stored as unix nanoseconds.
+type sqlStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore creates a persistent denylist store on top of db.
+//
+// The schema must exist: call MigrateSQL first.
+func NewSQLStore(db *sql.DB, dialect Dialect) Store {
+	return &sqlStore{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// MigrateSQL creates the denylist table. Safe to call on every start.
+func MigrateSQL(
+	ctx context.Context,
+	db *sql.DB,
+) error {
+
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS revoked_tokens (
+			id         TEXT PRIMARY KEY,
+			expires_at BIGINT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS revoked_tokens_expires_idx ON revoked_tokens (expires_at)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("revocation: migrate: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *sqlStore) Add(
+	ctx context.Context,
+	id string,
+	expiresAt time.Time,
+) error {
+
+	// Portable upsert (SQLite >= 3.24, Postgres): keep the later expiry.
+	_, err := s.db.ExecContext(ctx, s.rebind(
+		`INSERT INTO revoked_tokens (id, expires_at) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET expires_at = excluded.expires_at
+		WHERE excluded.expires_at > revoked_tokens.expires_at`),
+		id, expiresAt.UnixNano(),
+	)
+	return err
+}
+
+func (s *sqlStore) Contains(
+	ctx context.Context,
+	id string,
+	now time.Time,
+) (bool, error) {
+
+	var one int
+	err := s.db.QueryRowContext(ctx, s.rebind(
+		`SELECT 1 FROM revoked_tokens WHERE id = ? AND expires_at > ?`),
+		id, now.UnixNano(),
+	).Scan(&one)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		return false, err
+	default:
+		return true, nil
+	}
+}
+
+func (s *sqlStore) ListActive(
+	ctx context.Context,
+	now time.Time,
+) ([]string, error) {
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT id FROM revoked_tokens WHERE expires_at > ?`),
+		now.UnixNano(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *sqlStore) DeleteExpired(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+
+	res, err := s.db.ExecContext(ctx, s.rebind(
+		`DELETE FROM revoked_tokens WHERE expires_at <= ?`),
+		now.UnixNano(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// rebind converts "?" placeholders to "$n" for Postgres.
+func (s *sqlStore) rebind(query string) string {
+	if s.dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store persists denied IDs until they expire.
+//
+// IDs are opaque to the store (see Denylist for the key scheme).
+type Store interface {
+
+	// Add denies id until expiresAt.
+	//
+	// Expected behavior:
+	//   - Idempotent; re-adding keeps the later expiry
+	Add(
+		ctx context.Context,
+		id string,
+		expiresAt time.Time,
+	) error
+
+	// Contains reports whether id is denied at now.
+	Contains(
+		ctx context.Context,
+		id string,
+		now time.Time,
+	) (bool, error)
+
+	// ListActive returns every ID still denied at now
+	// (used to build the in-memory filter).
+	ListActive(
+		ctx context.Context,
+		now time.Time,
+	) ([]string, error)
+
+	// DeleteExpired removes entries that expired at or before now.
+	DeleteExpired(
+		ctx context.Context,
+		now time.Time,
+	) (int, error)
+}
+
+// memoryStore is a single-instance Store.
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewMemoryStore creates an in-memory denylist store.
+//
+// Revocations are lost on restart: use a persistent store
+// when access tokens outlive a deploy.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[string]time.Time),
+	}
+}
+
+func (s *memoryStore) Add(
+	ctx context.Context,
+	id string,
+	expiresAt time.Time,
+) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cur, ok := s.entries[id]; !ok || expiresAt.After(cur) {
+		s.entries[id] = expiresAt
+	}
+	return nil
+}
+
+func (s *memoryStore) Contains(
+	ctx context.Context,
+	id string,
+	now time.Time,
+) (bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exp, ok := s.entries[id]
+	return ok && now.Before(exp), nil
+}
+
+func (s *memoryStore) ListActive(
+	ctx context.Context,
+	now time.Time,
+) ([]string, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.entries))
+	for id, exp := range s.entries {
+		if now.Before(exp) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *memoryStore) DeleteExpired(
+	ctx context.Context,
+	now time.Time,
+) (int, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, exp := range s.entries {
+		if !now.Before(exp) {
+			delete(s.entries, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,179 @@
+// Package tokentest provides a conformance suite for token.Issuer
+// implementations that sign with a keys.Provider.
+//
+// Every issuer (JWT, PASETO v2.local, v4.public, ...) must follow key
+// rotation the same way:
+//
+//	func TestJWTIssuerRotation(t *testing.T) {
+//		tokentest.RunIssuerRotationTests(t, tokentest.IssuerSuite{
+//			Algorithm: keys.ES256,
+//			NewIssuer: func(kp keys.Provider) (token.Issuer, error) {
+//				return jwt.NewIssuer(kp, "test", time.Minute)
+//			},
+//			Verifier: jwt.NewVerifier("test", ""),
+//		})
+//	}
+package tokentest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/kararnab/authdemo/pkg/iam/token"
+	"github.com/kararnab/authdemo/pkg/iam/token/keys"
+)
+
+// Verifier verifies tokens of the issuer under test and reads their kid.
+type Verifier interface {
+	token.SingleVerifier
+	token.KeyIDExtractor
+}
+
+// IssuerSuite describes the issuer under test.
+type IssuerSuite struct {
+	// Algorithm of the generated test keys.
+	Algorithm keys.Algorithm
+
+	// NewIssuer creates the issuer; it must read kp's active key on
+	// every Issue.
+	NewIssuer func(kp keys.Provider) (token.Issuer, error)
+
+	// Verifier verifies the issued tokens.
+	Verifier Verifier
+}
+
+// RunIssuerRotationTests runs the key rotation conformance suite.
+func RunIssuerRotationTests(t *testing.T, s IssuerSuite) {
+	t.Helper()
+
+	t.Run("TokensAfterRotationCarryNewKeyID", func(t *testing.T) {
+		ctx := context.Background()
+		k1, k2 := newKey(t, s.Algorithm, "k1"), newKey(t, s.Algorithm, "k2")
+		kp := keys.NewMemoryProvider(k1)
+
+		iss, err := s.NewIssuer(kp)
+		if err != nil {
+			t.Fatalf("NewIssuer: %v", err)
+		}
+
+		before := issue(t, iss)
+		expectKeyID(t, s.Verifier, before, "k1")
+
+		kp.Rotate(k2)
+
+		after := issue(t, iss)
+		expectKeyID(t, s.Verifier, after, "k2")
+
+		// Signed (or encrypted) with the key its kid names, not the old one.
+		if _, err := s.Verifier.VerifyWithKey(ctx, after, k2); err != nil {
+			t.Fatalf("token after rotation does not verify with the new key: %v", err)
+		}
+		if _, err := s.Verifier.VerifyWithKey(ctx, after, k1); err == nil {
+			t.Fatal("token after rotation verifies with the old key")
+		}
+
+		// Tokens issued before rotation still verify with the old key.
+		if _, err := s.Verifier.VerifyWithKey(ctx, before, k1); err != nil {
+			t.Fatalf("token before rotation no longer verifies: %v", err)
+		}
+	})
+
+	t.Run("KeyIDMatchesKeyDuringConcurrentRotation", func(t *testing.T) {
+		ctx := context.Background()
+		kp := keys.NewMemoryProvider(newKey(t, s.Algorithm, "k0"))
+
+		iss, err := s.NewIssuer(kp)
+		if err != nil {
+			t.Fatalf("NewIssuer: %v", err)
+		}
+
+		const rotations, issuers, perIssuer = 10, 4, 20
+
+		rotated := make([]keys.Key, rotations)
+		for i := range rotated {
+			rotated[i] = newKey(t, s.Algorithm, fmt.Sprintf("k%d", i+1))
+		}
+
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			tokens []string
+		)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, k := range rotated {
+				kp.Rotate(k)
+			}
+		}()
+		for range issuers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range perIssuer {
+					tkn, err := iss.Issue(ctx, token.Claims{SubjectID: "user-1"})
+					if err != nil {
+						t.Errorf("Issue: %v", err)
+						return
+					}
+					mu.Lock()
+					tokens = append(tokens, tkn)
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		for _, tkn := range tokens {
+			kid, ok := s.Verifier.KeyID(tkn)
+			if !ok {
+				t.Fatal("token has no kid")
+			}
+			key, ok := kp.KeyByID(kid)
+			if !ok {
+				t.Fatalf("token kid %q is not a known key", kid)
+			}
+			if _, err := s.Verifier.VerifyWithKey(ctx, tkn, key); err != nil {
+				t.Fatalf("token does not verify with the key its kid %q names: %v", kid, err)
+			}
+		}
+	})
+
+	t.Run("RejectsProviderWithoutSigningKey", func(t *testing.T) {
+		// e.g. a verify-only provider (remote JWKS)
+		if _, err := s.NewIssuer(keys.NewMemoryProvider(keys.Key{})); err == nil {
+			t.Fatal("NewIssuer accepted a provider without an active key")
+		}
+	})
+}
+
+func newKey(t *testing.T, alg keys.Algorithm, id string) keys.Key {
+	t.Helper()
+
+	k, err := keys.Generate(id, alg)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return k
+}
+
+func issue(t *testing.T, iss token.Issuer) string {
+	t.Helper()
+
+	tkn, err := iss.Issue(context.Background(), token.Claims{SubjectID: "user-1"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return tkn
+}
+
+func expectKeyID(t *testing.T, v Verifier, tkn, want string) {
+	t.Helper()
+
+	kid, ok := v.KeyID(tkn)
+	if !ok || kid != want {
+		t.Fatalf("kid = %q, want %q", kid, want)
+	}
+}
@@ -13,10 +13,9 @@ type Verifier interface {
 
 	// Verify validates an access token and returns its claims.
 	//
-	// TODO:
-	//   - Support multiple token formats simultaneously
-	//   - Key rotation / grace periods
-	//   - Optional introspection fallback
+	// Implementations: jwt / paseto (local keys), opaque (token
+	// store), introspection (remote IAM, RFC 7662) and
+	// CompositeVerifier (several formats / issuers at once).
 	Verify(
 		ctx context.Context,
 		accessToken string,
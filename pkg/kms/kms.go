@@ -0,0 +1,139 @@
+// Package kms abstracts the key management service that holds root
+// keys (key-encryption and signing keys) outside the application.
+//
+// Callers only see key IDs, never root key material, so a cloud KMS
+// (AWS KMS, GCP KMS, Vault transit, ...) can replace the local
+// implementations without changes to callers.
+//
+// Envelope encryption: GenerateDataKey returns a fresh data key and
+// its copy encrypted under a root key; data is encrypted locally with
+// the data key and only the (small) encrypted data key is stored next
+// to it. Decrypting it is the only KMS call needed to read the data.
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// DataKeySize is the size of generated data keys (AES-256).
+const DataKeySize = 32
+
+var (
+	ErrKeyNotFound = errors.New("kms: key not found")
+	ErrUnsupported = errors.New("kms: operation not supported by key")
+	ErrDecrypt     = errors.New("kms: decryption failed")
+)
+
+// KeySpec is the type of a root key.
+type KeySpec string
+
+const (
+	KeySpecAES256    KeySpec = "AES_256"           // Encrypt / Decrypt / GenerateDataKey
+	KeySpecEd25519   KeySpec = "ED25519"           // Sign (message)
+	KeySpecECDSAP256 KeySpec = "ECDSA_P256_SHA256" // Sign (SHA-256 digest, ASN.1 DER)
+)
+
+// KMS performs cryptographic operations with root keys it never
+// reveals.
+//
+// aad (associated data) binds ciphertexts to their context: Decrypt
+// fails unless it gets the aad used to encrypt.
+type KMS interface {
+
+	// Encrypt encrypts a small plaintext (e.g. a data key) with the
+	// symmetric key keyID.
+	Encrypt(
+		ctx context.Context,
+		keyID string,
+		plaintext []byte,
+		aad []byte,
+	) ([]byte, error)
+
+	// Decrypt reverses Encrypt (ErrDecrypt on wrong key, aad or
+	// tampered ciphertext).
+	Decrypt(
+		ctx context.Context,
+		keyID string,
+		ciphertext []byte,
+		aad []byte,
+	) ([]byte, error)
+
+	// GenerateDataKey returns a fresh DataKeySize data key and its
+	// copy encrypted with keyID (decryptable with Decrypt).
+	GenerateDataKey(
+		ctx context.Context,
+		keyID string,
+		aad []byte,
+	) (plaintext []byte, ciphertext []byte, err error)
+
+	// Sign signs message with the asymmetric key keyID.
+	Sign(
+		ctx context.Context,
+		keyID string,
+		message []byte,
+	) ([]byte, error)
+
+	// PublicKey returns the public half of the asymmetric key keyID.
+	PublicKey(
+		ctx context.Context,
+		keyID string,
+	) (crypto.PublicKey, error)
+}
+
+// newDataKey generates a data key.
+func newDataKey() ([]byte, error) {
+	dk := make([]byte, DataKeySize)
+	if _, err := rand.Read(dk); err != nil {
+		return nil, err
+	}
+	return dk, nil
+}
+
+// seal is AES-256-GCM; the nonce is prepended to the ciphertext.
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open reverses seal.
+func open(key, sealed, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("kms: AES-256 key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
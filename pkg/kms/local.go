@@ -0,0 +1,294 @@
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// localFileVersion is bumped on incompatible changes of the file layout.
+const localFileVersion = 1
+
+// localKey is a root key as stored in the local KMS file.
+type localKey struct {
+	Spec      KeySpec   `json:"spec"`
+	Material  []byte    `json:"material"` // AES key, or PKCS#8 private key
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type localFile struct {
+	Version int                 `json:"version"`
+	Keys    map[string]localKey `json:"keys"`
+}
+
+// Local is a file-backed KMS for development and CI.
+//
+// Root keys are stored UNENCRYPTED in one JSON file (mode 0600):
+// the file plays the role of the KMS / HSM and must be kept apart
+// from the data it protects. Not for production.
+type Local struct {
+	path string
+
+	mu   sync.RWMutex
+	keys map[string]localKey
+}
+
+// NewLocal opens the local KMS at path (created by the first CreateKey).
+func NewLocal(path string) (*Local, error) {
+	l := &Local{
+		path: path,
+		keys: make(map[string]localKey),
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kms: read %s: %w", path, err)
+	}
+
+	var f localFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("kms: parse %s: %w", path, err)
+	}
+	if f.Version != localFileVersion {
+		return nil, fmt.Errorf("kms: unsupported file version %d", f.Version)
+	}
+	for id, k := range f.Keys {
+		l.keys[id] = k
+	}
+
+	return l, nil
+}
+
+// CreateKey creates the root key id unless it exists.
+//
+// Idempotent: an existing key with the same spec is kept (its material
+// never changes); a different spec is an error.
+func (l *Local) CreateKey(_ context.Context, id string, spec KeySpec) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if k, ok := l.keys[id]; ok {
+		if k.Spec != spec {
+			return fmt.Errorf("kms: key %q exists with spec %s", id, k.Spec)
+		}
+		return nil
+	}
+
+	material, err := generateMaterial(spec)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]localKey, len(l.keys)+1)
+	for kid, k := range l.keys {
+		keys[kid] = k
+	}
+	keys[id] = localKey{
+		Spec:      spec,
+		Material:  material,
+		CreatedAt: time.Now(),
+	}
+
+	if err := l.save(keys); err != nil {
+		return err
+	}
+	l.keys = keys
+	return nil
+}
+
+func (l *Local) Encrypt(
+	_ context.Context,
+	keyID string,
+	plaintext []byte,
+	aad []byte,
+) ([]byte, error) {
+
+	key, err := l.symmetric(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, plaintext, aad)
+}
+
+func (l *Local) Decrypt(
+	_ context.Context,
+	keyID string,
+	ciphertext []byte,
+	aad []byte,
+) ([]byte, error) {
+
+	key, err := l.symmetric(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, ciphertext, aad)
+}
+
+func (l *Local) GenerateDataKey(
+	ctx context.Context,
+	keyID string,
+	aad []byte,
+) ([]byte, []byte, error) {
+
+	dk, err := newDataKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := l.Encrypt(ctx, keyID, dk, aad)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dk, wrapped, nil
+}
+
+// Sign signs message: Ed25519 signs it as is, ECDSA P-256 signs its
+// SHA-256 digest (ASN.1 DER signature).
+func (l *Local) Sign(
+	_ context.Context,
+	keyID string,
+	message []byte,
+) ([]byte, error) {
+
+	priv, spec, err := l.signer(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch spec {
+	case KeySpecEd25519:
+		return priv.Sign(rand.Reader, message, crypto.Hash(0))
+	default:
+		digest := sha256.Sum256(message)
+		return priv.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+}
+
+func (l *Local) PublicKey(
+	_ context.Context,
+	keyID string,
+) (crypto.PublicKey, error) {
+
+	priv, _, err := l.signer(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Public(), nil
+}
+
+// symmetric returns the material of an AES key.
+func (l *Local) symmetric(keyID string) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	k, ok := l.keys[keyID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	if k.Spec != KeySpecAES256 {
+		return nil, ErrUnsupported
+	}
+	return k.Material, nil
+}
+
+// signer parses the private key of a signing key.
+func (l *Local) signer(keyID string) (crypto.Signer, KeySpec, error) {
+	l.mu.RLock()
+	k, ok := l.keys[keyID]
+	l.mu.RUnlock()
+
+	if !ok {
+		return nil, "", ErrKeyNotFound
+	}
+	if k.Spec != KeySpecEd25519 && k.Spec != KeySpecECDSAP256 {
+		return nil, "", ErrUnsupported
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(k.Material)
+	if err != nil {
+		return nil, "", fmt.Errorf("kms: invalid key %q", keyID)
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, "", fmt.Errorf("kms: invalid key %q", keyID)
+	}
+	return signer, k.Spec, nil
+}
+
+// generateMaterial creates the material of a new root key.
+func generateMaterial(spec KeySpec) ([]byte, error) {
+	switch spec {
+	case KeySpecAES256:
+		return newDataKey()
+
+	case KeySpecEd25519:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return x509.MarshalPKCS8PrivateKey(priv)
+
+	case KeySpecECDSAP256:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return x509.MarshalPKCS8PrivateKey(priv)
+
+	default:
+		return nil, errors.New("kms: unsupported key spec " + string(spec))
+	}
+}
+
+// save writes the key file atomically (temp file, fsync, rename).
+func (l *Local) save(keys map[string]localKey) error {
+	raw, err := json.MarshalIndent(localFile{
+		Version: localFileVersion,
+		Keys:    keys,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(l.path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(l.path)+".*")
+	if err != nil {
+		return fmt.Errorf("kms: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("kms: %w", err)
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("kms: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("kms: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("kms: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("kms: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package kms
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// SecretPrefix marks a secret_store value wrapped by a KMS:
+// "kms:" + base64(ciphertext).
+const SecretPrefix = "kms:"
+
+// WrapSecret encrypts the secret called name with keyID, for storage
+// in configuration. The name is bound as associated data: a wrapped
+// value only unwraps under its own name.
+func WrapSecret(
+	ctx context.Context,
+	k KMS,
+	keyID string,
+	name string,
+	value string,
+) (string, error) {
+
+	ciphertext, err := k.Encrypt(ctx, keyID, []byte(value), secretAAD(name))
+	if err != nil {
+		return "", err
+	}
+	return SecretPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// UnwrapSecret returns the plaintext of a secret: values with
+// SecretPrefix are decrypted with keyID, others are returned as is.
+//
+// k may be nil when no secret is wrapped.
+func UnwrapSecret(
+	ctx context.Context,
+	k KMS,
+	keyID string,
+	name string,
+	value string,
+) (string, error) {
+
+	encoded, wrapped := strings.CutPrefix(value, SecretPrefix)
+	if !wrapped {
+		return value, nil
+	}
+	if k == nil {
+		return "", errors.New("kms: " + name + " is wrapped but no KMS is configured")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("kms: " + name + " is not valid base64")
+	}
+	plaintext, err := k.Decrypt(ctx, keyID, ciphertext, secretAAD(name))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func secretAAD(name string) []byte {
+	return []byte("authdemo-secret:" + name)
+}
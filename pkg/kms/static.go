@@ -0,0 +1,81 @@
+package kms
+
+import (
+	"context"
+	"crypto"
+	"errors"
+)
+
+// staticKMS holds one AES-256 key given by configuration
+// (e.g. a master key from the secret store).
+type staticKMS struct {
+	keyID string
+	key   []byte
+}
+
+// NewStatic creates a KMS with a single symmetric key (32 bytes)
+// named keyID.
+//
+// The key lives in process memory: use it for keys from a secret
+// store or ephemeral keys, and a real KMS where possible.
+func NewStatic(keyID string, key []byte) (KMS, error) {
+	if len(key) != 32 {
+		return nil, errors.New("kms: static key must be 32 bytes")
+	}
+
+	return &staticKMS{
+		keyID: keyID,
+		key:   append([]byte(nil), key...),
+	}, nil
+}
+
+func (s *staticKMS) Encrypt(
+	_ context.Context,
+	keyID string,
+	plaintext []byte,
+	aad []byte,
+) ([]byte, error) {
+
+	if keyID != s.keyID {
+		return nil, ErrKeyNotFound
+	}
+	return seal(s.key, plaintext, aad)
+}
+
+func (s *staticKMS) Decrypt(
+	_ context.Context,
+	keyID string,
+	ciphertext []byte,
+	aad []byte,
+) ([]byte, error) {
+
+	if keyID != s.keyID {
+		return nil, ErrKeyNotFound
+	}
+	return open(s.key, ciphertext, aad)
+}
+
+func (s *staticKMS) GenerateDataKey(
+	ctx context.Context,
+	keyID string,
+	aad []byte,
+) ([]byte, []byte, error) {
+
+	dk, err := newDataKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := s.Encrypt(ctx, keyID, dk, aad)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dk, wrapped, nil
+}
+
+func (s *staticKMS) Sign(context.Context, string, []byte) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *staticKMS) PublicKey(context.Context, string) (crypto.PublicKey, error) {
+	return nil, ErrUnsupported
+}
@@ -10,12 +10,35 @@ type IAMMetrics interface {
 
 	TokenVerifySuccess()
 	TokenVerifyFailure()
+	TokenRevoked() // valid access token rejected by the revocation denylist
+
+	// Token exchange (RFC 8693)
+	TokenExchangeSuccess()
+	TokenExchangeFailure()
+
+	// Verification key selection (see token.MultiVerifier)
+	TokenKeyLookup()       // key found by kid
+	TokenKeyUnknown()      // kid present but not a known key
+	TokenKeyScanFallback() // no kid, all keys tried
+
+	// Remote JWKS (see keys.RemoteProvider)
+	JWKSFetchSuccess() // key set fetched or revalidated
+	JWKSFetchFailure() // fetch failed (cached keys kept)
 
 	TokenRefreshSuccess()
 	TokenRefreshFailure()
+	RefreshTokenReuse()
 
 	SessionRevokeSuccess()
 	SessionRevokeFailure()
 
+	// Concurrent session limits (see session.LimitPolicy)
+	SessionEvicted()       // existing session ended to admit a login
+	SessionLimitRejected() // login refused at the limit
+
+	// Session janitor (see session.Janitor)
+	SessionsSwept(n int)  // expired sessions removed
+	SessionsActive(n int) // gauge: unexpired sessions in the store
+
 	PolicyDenied()
 }
@@ -17,12 +17,27 @@ type IAMMetrics struct {
 
 	verifySuccess prometheus.Counter
 	verifyFailure prometheus.Counter
+	tokenRevoked  prometheus.Counter
+
+	exchangeSuccess prometheus.Counter
+	exchangeFailure prometheus.Counter
+
+	keyLookup       prometheus.Counter
+	keyUnknown      prometheus.Counter
+	keyScanFallback prometheus.Counter
+	jwksFetchOK     prometheus.Counter
+	jwksFetchFailed prometheus.Counter
 
 	refreshSuccess prometheus.Counter
 	refreshFailure prometheus.Counter
+	refreshReuse   prometheus.Counter
 
 	sessionRevokeSuccess prometheus.Counter
 	sessionRevokeFailure prometheus.Counter
+	sessionEvicted       prometheus.Counter
+	sessionLimitRejected prometheus.Counter
+	sessionsSwept        prometheus.Counter
+	sessionsActive       prometheus.Gauge
 
 	policyDenied prometheus.Counter
 }
@@ -54,6 +69,54 @@ func NewIAMMetrics(reg prometheus.Registerer) metrics.IAMMetrics {
 			Name:      "token_verify_failure_total",
 			Help:      "Failed access token verifications",
 		}),
+		tokenRevoked: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "access_token_revoked_total",
+			Help:      "Access tokens rejected because they (or their session) were revoked",
+		}),
+		exchangeSuccess: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "token_exchange_success_total",
+			Help:      "Access tokens issued by token exchange",
+		}),
+		exchangeFailure: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "token_exchange_failure_total",
+			Help:      "Rejected token exchange requests",
+		}),
+		keyLookup: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "token_key_lookup_total",
+			Help:      "Verification keys selected directly by kid",
+		}),
+		keyUnknown: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "token_key_unknown_total",
+			Help:      "Tokens rejected because their kid matches no verification key",
+		}),
+		keyScanFallback: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "token_key_scan_fallback_total",
+			Help:      "Tokens without kid verified by trying every key (legacy path)",
+		}),
+		jwksFetchOK: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "jwks_fetch_success_total",
+			Help:      "Remote JWKS documents fetched or revalidated",
+		}),
+		jwksFetchFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "jwks_fetch_failure_total",
+			Help:      "Failed remote JWKS fetches (cached keys kept)",
+		}),
 		refreshSuccess: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
@@ -66,6 +129,12 @@ func NewIAMMetrics(reg prometheus.Registerer) metrics.IAMMetrics {
 			Name:      "token_refresh_failure_total",
 			Help:      "Failed refresh token operations",
 		}),
+		refreshReuse: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "refresh_token_reuse_total",
+			Help:      "Rotated refresh tokens presented again (session family revoked)",
+		}),
 		sessionRevokeSuccess: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
@@ -78,6 +147,30 @@ func NewIAMMetrics(reg prometheus.Registerer) metrics.IAMMetrics {
 			Name:      "session_revoke_failure_total",
 			Help:      "Failed revoke session operations",
 		}),
+		sessionEvicted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "session_evicted_total",
+			Help:      "Sessions ended to respect concurrent session limits",
+		}),
+		sessionLimitRejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "session_limit_rejected_total",
+			Help:      "Logins refused because a concurrent session limit was reached",
+		}),
+		sessionsSwept: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "sessions_swept_total",
+			Help:      "Expired sessions removed by the session janitor",
+		}),
+		sessionsActive: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "sessions_active",
+			Help:      "Unexpired sessions in the store (as of the last sweep)",
+		}),
 		policyDenied: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
@@ -91,10 +184,23 @@ func NewIAMMetrics(reg prometheus.Registerer) metrics.IAMMetrics {
 		m.authFailure,
 		m.verifySuccess,
 		m.verifyFailure,
+		m.tokenRevoked,
+		m.exchangeSuccess,
+		m.exchangeFailure,
+		m.keyLookup,
+		m.keyUnknown,
+		m.keyScanFallback,
+		m.jwksFetchOK,
+		m.jwksFetchFailed,
 		m.refreshSuccess,
 		m.refreshFailure,
+		m.refreshReuse,
 		m.sessionRevokeSuccess,
 		m.sessionRevokeFailure,
+		m.sessionEvicted,
+		m.sessionLimitRejected,
+		m.sessionsSwept,
+		m.sessionsActive,
 		m.policyDenied,
 	)
 
@@ -107,8 +213,21 @@ func (m *IAMMetrics) AuthSuccess()          { m.authSuccess.Inc() }
 func (m *IAMMetrics) AuthFailure()          { m.authFailure.Inc() }
 func (m *IAMMetrics) TokenVerifySuccess()   { m.verifySuccess.Inc() }
 func (m *IAMMetrics) TokenVerifyFailure()   { m.verifyFailure.Inc() }
+func (m *IAMMetrics) TokenRevoked()         { m.tokenRevoked.Inc() }
+func (m *IAMMetrics) TokenExchangeSuccess() { m.exchangeSuccess.Inc() }
+func (m *IAMMetrics) TokenExchangeFailure() { m.exchangeFailure.Inc() }
+func (m *IAMMetrics) TokenKeyLookup()       { m.keyLookup.Inc() }
+func (m *IAMMetrics) TokenKeyUnknown()      { m.keyUnknown.Inc() }
+func (m *IAMMetrics) TokenKeyScanFallback() { m.keyScanFallback.Inc() }
+func (m *IAMMetrics) JWKSFetchSuccess()     { m.jwksFetchOK.Inc() }
+func (m *IAMMetrics) JWKSFetchFailure()     { m.jwksFetchFailed.Inc() }
 func (m *IAMMetrics) TokenRefreshSuccess()  { m.refreshSuccess.Inc() }
 func (m *IAMMetrics) TokenRefreshFailure()  { m.refreshFailure.Inc() }
+func (m *IAMMetrics) RefreshTokenReuse()    { m.refreshReuse.Inc() }
 func (m *IAMMetrics) SessionRevokeSuccess() { m.sessionRevokeSuccess.Inc() }
 func (m *IAMMetrics) SessionRevokeFailure() { m.sessionRevokeFailure.Inc() }
+func (m *IAMMetrics) SessionEvicted()       { m.sessionEvicted.Inc() }
+func (m *IAMMetrics) SessionLimitRejected() { m.sessionLimitRejected.Inc() }
+func (m *IAMMetrics) SessionsSwept(n int)   { m.sessionsSwept.Add(float64(n)) }
+func (m *IAMMetrics) SessionsActive(n int)  { m.sessionsActive.Set(float64(n)) }
 func (m *IAMMetrics) PolicyDenied()         { m.policyDenied.Inc() }
@@ -19,8 +19,48 @@ func (d *DummyStore) Get(_ context.Context, name string) (string, error) {
 		return "user-1", nil
 	case "SECRET_JWT_SIGNING_KEY":
 		return "dev-secret", nil
+	case "JWT_SIGNING_ALG":
+		return "HS256", nil // HS256 | RS256 | ES256 | EdDSA
+	case "TOKEN_FORMAT":
+		return "", nil // jwt | paseto | paseto-public | opaque (empty: paseto if SECRET_PASETO_SIGNING_KEY is set, else jwt)
 	case "SECRET_PASETO_SIGNING_KEY":
+		return "", nil // base64 32 bytes, or "kms:..." wrapped with the KMS (go run ./cmd/kmswrap)
+	case "KEYRING_STORE":
+		return "memory", nil // memory | file | sql (signing keys; created from the keys above, then the source of truth; file (same host) / sql can be shared by replicas)
+	case "KEYRING_PATH":
+		return "", nil // file store, default: keyring-<TOKEN_FORMAT>.json
+	case "SECRET_KEYRING_MASTER_KEY":
+		return "", nil // base64 32 bytes, file / sql need it or a KMS (e.g. `openssl rand -base64 32`)
+	case "KMS_PROVIDER":
+		return "", nil // local (dev / CI; empty: SECRET_KEYRING_MASTER_KEY wraps keyring keys)
+	case "KMS_LOCAL_PATH":
+		return "", nil // local KMS file, default: kms-local.json
+	case "KMS_KEY_ID":
+		return "", nil // wrapping key, default: authdemo
+	case "KEY_ROTATION_INTERVAL":
+		return "", nil // automatic signing key rotation, e.g. "720h" (empty: manual via /admin/keys/rotate)
+	case "SESSION_STORE":
+		return "memory", nil // memory | sqlite | redis
+	case "SESSION_SQLITE_PATH":
+		return "", nil // default: sessions.db
+	case "REDIS_ADDR":
+		return "localhost:6379", nil
+	case "SECRET_REDIS_PASSWORD":
 		return "", nil
+	case "TRUSTED_PROXIES":
+		return "", nil // e.g. "10.0.0.0/8,127.0.0.1"
+	case "LEGACY_VERIFIERS":
+		return "", nil // formats still verified after a TOKEN_FORMAT switch, e.g. "jwt,paseto" (empty: none)
+	case "LEGACY_VERIFIERS_UNTIL":
+		return "", nil // RFC 3339 sunset of LEGACY_VERIFIERS, e.g. "2026-12-31T00:00:00Z"
+	case "TRUSTED_ISSUERS":
+		return "", nil // external JWT issuers, e.g. "https://idp.example.com|https://idp.example.com/jwks.json"
+	case "SECRET_OAUTH_CLIENTS":
+		return "", nil // introspection / token exchange clients, e.g. "books-api:s3cret,orders-api:0th3r"
+	case "DPOP_MODE":
+		return "", nil // off | optional | required
+	case "PUBLIC_BASE_URL":
+		return "", nil // e.g. "https://auth.example.com"
 	case "GOOGLE_OAUTH_CLIENTID":
 		return "", nil
 	default: